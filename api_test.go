@@ -4,10 +4,30 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 )
 
+func TestNewSession_W3C(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"sessionId": "abc", "capabilities": {"browserName": "firefox"}}}`)
+	}))
+	defer srv.Close()
+
+	wd, err := NewRemote(caps, srv.URL)
+	if err != nil {
+		t.Fatalf("NewRemote returned error: %v", err)
+	}
+	rwd := wd.(*remoteWebDriver)
+	if rwd.id != "abc" {
+		t.Errorf("session id = %q, want %q", rwd.id, "abc")
+	}
+	if !rwd.w3c {
+		t.Error("session not detected as W3C")
+	}
+}
+
 func TestExecuteScript_Args(t *testing.T) {
 	setup()
 	defer teardown()
@@ -0,0 +1,61 @@
+package selenium
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAttach(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"sessionId": "123", "status": 0, "value": {"browserName": "firefox"}}`)
+	})
+
+	wd, err := Attach(server.URL, "123")
+	if err != nil {
+		t.Fatalf("Attach returned error: %v", err)
+	}
+	if id := wd.SessionID(); id != "123" {
+		t.Errorf("SessionID returned %q, want 123", id)
+	}
+	if rwd := wd.(*remoteWebDriver); rwd.w3c || rwd.sessionCaps["browserName"] != "firefox" {
+		t.Errorf("got w3c=%v and capabilities %v, want a JSON Wire firefox session", rwd.w3c, rwd.sessionCaps)
+	}
+}
+
+func TestAttach_W3C(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/session/abc/timeouts", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": {"script": 30000, "pageLoad": 300000, "implicit": 0}}`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "unknown command", "message": ""}}`)
+	})
+	mux.HandleFunc("/session/gone/timeouts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "invalid session id", "message": "session gone"}}`)
+	})
+	s := httptest.NewServer(mux)
+	defer s.Close()
+
+	wd, err := Attach(s.URL, "abc")
+	if err != nil {
+		t.Fatalf("Attach returned error: %v", err)
+	}
+	if !wd.(*remoteWebDriver).w3c {
+		t.Error("Attach did not detect the W3C dialect")
+	}
+	if id := wd.SessionID(); id != "abc" {
+		t.Errorf("SessionID returned %q, want abc", id)
+	}
+
+	if _, err := Attach(s.URL, "gone"); err == nil || err.Error() != "invalid session id" {
+		t.Errorf("Attach to a missing session returned %v, want invalid session id", err)
+	}
+}
@@ -0,0 +1,330 @@
+// Package bidi implements a client for the WebDriver BiDi protocol, which
+// streams events such as console messages, page loads and network requests
+// from the browser over a WebSocket.
+//
+// The remote end only opens a BiDi connection for sessions that ask for it
+// with the webSocketUrl capability:
+//
+//	caps := selenium.Capabilities{"browserName": "firefox", "webSocketUrl": true}
+//	wd, err := selenium.NewRemote(caps, executor)
+//	...
+//	conn, err := bidi.Connect(wd)
+//
+// See https://w3c.github.io/webdriver-bidi/.
+package bidi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"sourcegraph.com/sourcegraph/go-selenium"
+)
+
+// An Event is a message sent by the remote end to its subscribers.
+type Event struct {
+	// Method is the name of the event, e.g. "log.entryAdded".
+	Method string
+	// Params holds the event's JSON parameters.
+	Params json.RawMessage
+}
+
+// An Error is an error reported by the remote end in reply to a command.
+type Error struct {
+	// Code is the error code, e.g. "invalid argument".
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bidi: %s: %s", e.Code, e.Message)
+}
+
+// ErrClosed is returned by commands sent on a closed connection.
+var ErrClosed = errors.New("bidi: connection closed")
+
+// command is a message sent to the remote end.
+type command struct {
+	ID     int         `json:"id"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// message is a command result, error or event sent by the remote end.
+type message struct {
+	ID      *int            `json:"id"`
+	Type    string          `json:"type"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Result  json.RawMessage `json:"result"`
+	Error   string          `json:"error"`
+	Message string          `json:"message"`
+}
+
+// A Conn is a BiDi connection to a remote end. Commands may be sent from
+// several goroutines; events are delivered on the channels returned by
+// Subscribe.
+type Conn struct {
+	ws *wsConn
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan *message
+	subs    []*subscription
+	closed  bool
+}
+
+// Dial connects to the BiDi WebSocket at url.
+func Dial(url string) (*Conn, error) {
+	ws, err := dialWebSocket(url)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{ws: ws, pending: make(map[int]chan *message)}
+	go c.readLoop()
+	return c, nil
+}
+
+// Connect connects to the BiDi WebSocket of an existing session, given by
+// its webSocketUrl capability.
+func Connect(wd selenium.WebDriver) (*Conn, error) {
+	caps, err := wd.Capabilities()
+	if err != nil {
+		return nil, err
+	}
+	url, ok := caps["webSocketUrl"].(string)
+	if !ok || url == "" {
+		return nil, errors.New(`bidi: session has no webSocketUrl, create it with the "webSocketUrl": true capability`)
+	}
+	return Dial(url)
+}
+
+func (c *Conn) readLoop() {
+	for {
+		data, err := c.ws.readMessage()
+		if err != nil {
+			c.shutdown()
+			return
+		}
+		var msg message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+
+		if msg.Type == "event" || msg.ID == nil {
+			c.mu.Lock()
+			for _, s := range c.subs {
+				if s.matches(msg.Method) {
+					s.in <- Event{Method: msg.Method, Params: msg.Params}
+				}
+			}
+			c.mu.Unlock()
+			continue
+		}
+
+		c.mu.Lock()
+		ch := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- &msg
+		}
+	}
+}
+
+// shutdown fails pending commands and ends all subscriptions.
+func (c *Conn) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	for _, s := range c.subs {
+		close(s.in)
+	}
+	c.subs = nil
+}
+
+// Close closes the connection. Events not yet received from the
+// subscription channels are discarded.
+func (c *Conn) Close() error {
+	err := c.ws.close()
+	c.shutdown()
+	return err
+}
+
+// Send sends a command and decodes its result into result, unless result
+// is nil.
+func (c *Conn) Send(method string, params, result interface{}) error {
+	if params == nil {
+		params = struct{}{}
+	}
+	ch := make(chan *message, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	data, err := json.Marshal(command{ID: id, Method: method, Params: params})
+	if err == nil {
+		err = c.ws.writeMessage(data)
+	}
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return err
+	}
+
+	msg, ok := <-ch
+	if !ok {
+		return ErrClosed
+	}
+	if msg.Type == "error" {
+		return &Error{Code: msg.Error, Message: msg.Message}
+	}
+	if result != nil {
+		return json.Unmarshal(msg.Result, result)
+	}
+	return nil
+}
+
+// Subscribe subscribes to events, given by name (e.g. "log.entryAdded") or
+// by module (e.g. "network"), and returns a channel receiving them. The
+// channel is closed by Unsubscribe or when the connection closes. Events
+// are queued until received, so slow receivers don't block the connection.
+func (c *Conn) Subscribe(events ...string) (<-chan Event, error) {
+	s := &subscription{
+		events: events,
+		in:     make(chan Event),
+		out:    make(chan Event),
+	}
+	go s.run()
+
+	// Register first, so that no event sent right after the reply is lost.
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		close(s.in)
+		return nil, ErrClosed
+	}
+	c.subs = append(c.subs, s)
+	c.mu.Unlock()
+
+	if err := c.Send("session.subscribe", map[string][]string{"events": events}, nil); err != nil {
+		c.remove(s)
+		return nil, err
+	}
+	return s.out, nil
+}
+
+// remove removes a subscription and closes its channel.
+func (c *Conn) remove(s *subscription) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.subs {
+		if c.subs[i] == s {
+			close(s.in)
+			c.subs = append(c.subs[:i], c.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Unsubscribe ends a subscription returned by Subscribe and closes its
+// channel.
+func (c *Conn) Unsubscribe(ch <-chan Event) error {
+	c.mu.Lock()
+	var sub *subscription
+	for _, s := range c.subs {
+		if s.out == ch {
+			sub = s
+		}
+	}
+	c.mu.Unlock()
+	if sub == nil {
+		return nil
+	}
+	c.remove(sub)
+
+	c.mu.Lock()
+	events := sub.events
+	// Keep the events other subscriptions still want, including events
+	// of a module another subscription wants and modules of an event it
+	// wants.
+	var unused []string
+	for _, event := range events {
+		used := false
+		for _, s := range c.subs {
+			for _, e := range s.events {
+				used = used || covers(e, event) || covers(event, e)
+			}
+		}
+		if !used {
+			unused = append(unused, event)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(unused) == 0 {
+		return nil
+	}
+	return c.Send("session.unsubscribe", map[string][]string{"events": unused}, nil)
+}
+
+// subscription forwards events from the read loop to a subscriber,
+// queueing them until the subscriber receives them.
+type subscription struct {
+	events []string
+	in     chan Event
+	out    chan Event
+}
+
+func (s *subscription) matches(method string) bool {
+	for _, name := range s.events {
+		if covers(name, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether subscribing to name, an event or a module,
+// subscribes to event.
+func covers(name, event string) bool {
+	return event == name || strings.HasPrefix(event, name+".")
+}
+
+func (s *subscription) run() {
+	defer close(s.out)
+	var queue []Event
+	for {
+		var out chan Event
+		var next Event
+		if len(queue) > 0 {
+			out = s.out
+			next = queue[0]
+		}
+		select {
+		case ev, ok := <-s.in:
+			if !ok {
+				return
+			}
+			queue = append(queue, ev)
+		case out <- next:
+			queue = queue[1:]
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package bidi
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sourcegraph.com/sourcegraph/go-selenium"
+)
+
+func init() {
+	selenium.Log = nil
+}
+
+// stub is a BiDi remote end that answers commands with handle, then sends
+// the events queued on emit.
+type stub struct {
+	*httptest.Server
+	handle func(s *stub, cmd command) (result interface{}, errCode string)
+	emit   chan interface{}
+}
+
+func newStub(handle func(s *stub, cmd command) (interface{}, string)) *stub {
+	s := &stub{handle: handle, emit: make(chan interface{}, 10)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *stub) url() string {
+	return "ws" + strings.TrimPrefix(s.Server.URL, "http") + "/session/abc"
+}
+
+func (s *stub) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Upgrade") != "websocket" {
+		http.Error(w, "not a websocket handshake", http.StatusBadRequest)
+		return
+	}
+	conn, rw, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(r.Header.Get("Sec-WebSocket-Key")))
+	rw.Flush()
+
+	send := func(v interface{}) {
+		data, _ := json.Marshal(v)
+		writeFrame(conn, opText, data, false)
+	}
+	r2 := bufio.NewReader(rw)
+	for {
+		_, opcode, payload, err := readFrame(r2)
+		if err != nil || opcode == opClose {
+			return
+		}
+		var cmd command
+		json.Unmarshal(payload, &cmd)
+		result, errCode := s.handle(s, cmd)
+		if errCode != "" {
+			send(map[string]interface{}{"type": "error", "id": cmd.ID, "error": errCode, "message": "stub error"})
+			continue
+		}
+		send(map[string]interface{}{"type": "success", "id": cmd.ID, "result": result})
+		for len(s.emit) > 0 {
+			send(<-s.emit)
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	s := newStub(func(s *stub, cmd command) (interface{}, string) {
+		if cmd.Method != "session.status" {
+			return nil, "unknown command"
+		}
+		return map[string]interface{}{"ready": true, "message": strings.Repeat("x", 70000)}, ""
+	})
+	defer s.Close()
+
+	c, err := Dial(s.url())
+	if err != nil {
+		t.Fatalf("Dial returned error: %v", err)
+	}
+	defer c.Close()
+
+	var status struct {
+		Ready   bool
+		Message string
+	}
+	if err := c.Send("session.status", nil, &status); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	if !status.Ready || len(status.Message) != 70000 {
+		t.Errorf("Send got ready=%v and a %d byte message", status.Ready, len(status.Message))
+	}
+
+	err = c.Send("session.bogus", nil, nil)
+	if e, ok := err.(*Error); !ok || e.Code != "unknown command" {
+		t.Errorf("Send of unknown command returned %v, want unknown command error", err)
+	}
+}
+
+func TestLogEntries(t *testing.T) {
+	s := newStub(func(s *stub, cmd command) (interface{}, string) {
+		if cmd.Method == "session.subscribe" {
+			s.emit <- map[string]interface{}{
+				"type":   "event",
+				"method": "log.entryAdded",
+				"params": map[string]interface{}{
+					"type": "console", "level": "error", "text": "boom", "method": "error",
+					"timestamp": 1700000000000, "source": map[string]string{"context": "ctx1"},
+				},
+			}
+		}
+		return map[string]interface{}{}, ""
+	})
+	defer s.Close()
+
+	c, err := Dial(s.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _, err := c.LogEntries()
+	if err != nil {
+		t.Fatalf("LogEntries returned error: %v", err)
+	}
+	select {
+	case e := <-entries:
+		want := LogEntry{
+			Type: "console", Level: "error", Text: "boom", Method: "error",
+			Timestamp: time.Unix(1700000000, 0), Context: "ctx1",
+		}
+		if e != want {
+			t.Errorf("got log entry %+v, want %+v", e, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for log entry")
+	}
+
+	c.Close()
+	if _, ok := <-entries; ok {
+		t.Error("log entries channel not closed after Close")
+	}
+}
+
+func TestLogEntries_Cancel(t *testing.T) {
+	methods := make(chan string, 10)
+	s := newStub(func(s *stub, cmd command) (interface{}, string) {
+		methods <- cmd.Method
+		if cmd.Method == "session.subscribe" || cmd.Method == "session.status" {
+			s.emit <- map[string]interface{}{
+				"type":   "event",
+				"method": "log.entryAdded",
+				"params": map[string]interface{}{"type": "console", "text": cmd.Method},
+			}
+		}
+		return map[string]interface{}{}, ""
+	})
+	defer s.Close()
+
+	c, err := Dial(s.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	entries, cancel, err := c.LogEntries()
+	if err != nil {
+		t.Fatalf("LogEntries returned error: %v", err)
+	}
+	select {
+	case <-entries:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for log entry")
+	}
+	if err := cancel(); err != nil {
+		t.Fatalf("cancel returned error: %v", err)
+	}
+	if m := <-methods + " " + <-methods; m != "session.subscribe session.unsubscribe" {
+		t.Errorf("got commands %s, want session.subscribe session.unsubscribe", m)
+	}
+
+	// The stub sends another entry after replying.
+	if err := c.Send("session.status", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case e, ok := <-entries:
+		if ok {
+			t.Errorf("got log entry %+v after cancel", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("log entries channel not closed after cancel")
+	}
+}
+
+func TestUnsubscribe_Overlapping(t *testing.T) {
+	unsubscribed := make(chan string, 10)
+	s := newStub(func(s *stub, cmd command) (interface{}, string) {
+		if cmd.Method == "session.unsubscribe" {
+			unsubscribed <- fmt.Sprint(cmd.Params.(map[string]interface{})["events"])
+		}
+		return map[string]interface{}{}, ""
+	})
+	defer s.Close()
+
+	c, err := Dial(s.url())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	module, err := c.Subscribe("network")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := c.Subscribe("network.responseCompleted", "log.entryAdded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unsubscribe(events); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-unsubscribed, "[log.entryAdded]"; got != want {
+		t.Errorf("unsubscribed from %s, want %s", got, want)
+	}
+	if err := c.Unsubscribe(module); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-unsubscribed, "[network]"; got != want {
+		t.Errorf("unsubscribed from %s, want %s", got, want)
+	}
+
+	// A module stays subscribed while an event of it is wanted.
+	module, _ = c.Subscribe("network")
+	events, _ = c.Subscribe("network.responseCompleted")
+	if err := c.Unsubscribe(module); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Unsubscribe(events); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-unsubscribed, "[network.responseCompleted]"; got != want {
+		t.Errorf("unsubscribed from %s, want %s", got, want)
+	}
+}
+
+func TestDial_HandshakeTimeout(t *testing.T) {
+	defer func(d time.Duration) { handshakeTimeout = d }(handshakeTimeout)
+	handshakeTimeout = 50 * time.Millisecond
+
+	// A server that accepts connections but never answers the handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	if _, err := Dial("ws://" + l.Addr().String()); err == nil {
+		t.Fatal("Dial returned no error")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Dial returned after %s, want about 50ms", d)
+	}
+}
+
+func TestConnect(t *testing.T) {
+	s := newStub(func(s *stub, cmd command) (interface{}, string) {
+		return map[string]interface{}{}, ""
+	})
+	defer s.Close()
+
+	// A W3C remote end that only returns the capabilities on NewSession.
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/session" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"value": {"error": "unknown command", "message": ""}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"value": {"sessionId": "abc", "capabilities": {"webSocketUrl": %q}}}`, s.url())
+	}))
+	defer hub.Close()
+
+	wd, err := selenium.NewRemote(selenium.Capabilities{"webSocketUrl": true}, hub.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Connect(wd)
+	if err != nil {
+		t.Fatalf("Connect returned error: %v", err)
+	}
+	defer c.Close()
+	if err := c.Send("session.status", nil, nil); err != nil {
+		t.Errorf("Send returned error: %v", err)
+	}
+}
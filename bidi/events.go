@@ -0,0 +1,242 @@
+package bidi
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// timestamp is a BiDi timestamp, in milliseconds since the epoch.
+type timestamp float64
+
+func (t timestamp) time() time.Time {
+	return time.Unix(0, int64(float64(t)*float64(time.Millisecond)))
+}
+
+// A LogEntry is a console message or JavaScript error, see LogEntries.
+type LogEntry struct {
+	// Type is "console" or "javascript".
+	Type string
+	// Level is "debug", "info", "warn" or "error".
+	Level string
+	Text  string
+	// Method is the console method called, e.g. "log", for console entries.
+	Method    string
+	Timestamp time.Time
+	// Context is the ID of the browsing context that logged the entry.
+	Context string
+}
+
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Type      string    `json:"type"`
+		Level     string    `json:"level"`
+		Text      string    `json:"text"`
+		Method    string    `json:"method"`
+		Timestamp timestamp `json:"timestamp"`
+		Source    struct {
+			Context string `json:"context"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*e = LogEntry{
+		Type:      v.Type,
+		Level:     v.Level,
+		Text:      v.Text,
+		Method:    v.Method,
+		Timestamp: v.Timestamp.time(),
+		Context:   v.Source.Context,
+	}
+	return nil
+}
+
+// NavigationInfo describes a page load, see Loads.
+type NavigationInfo struct {
+	// Context is the ID of the browsing context that loaded the page.
+	Context    string
+	Navigation string
+	URL        string
+	Timestamp  time.Time
+}
+
+func (n *NavigationInfo) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Context    string    `json:"context"`
+		Navigation string    `json:"navigation"`
+		URL        string    `json:"url"`
+		Timestamp  timestamp `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*n = NavigationInfo{
+		Context:    v.Context,
+		Navigation: v.Navigation,
+		URL:        v.URL,
+		Timestamp:  v.Timestamp.time(),
+	}
+	return nil
+}
+
+// A Header is an HTTP header of a request or response.
+type Header struct {
+	Name  string
+	Value string
+}
+
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Name  string `json:"name"`
+		Value struct {
+			Value string `json:"value"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	h.Name, h.Value = v.Name, v.Value.Value
+	return nil
+}
+
+// A Request is the request of a NetworkEvent.
+type Request struct {
+	// ID identifies the request across its events.
+	ID      string   `json:"request"`
+	URL     string   `json:"url"`
+	Method  string   `json:"method"`
+	Headers []Header `json:"headers"`
+}
+
+// A Response is the response of a NetworkEvent.
+type Response struct {
+	URL        string   `json:"url"`
+	Status     int      `json:"status"`
+	StatusText string   `json:"statusText"`
+	MimeType   string   `json:"mimeType"`
+	FromCache  bool     `json:"fromCache"`
+	Headers    []Header `json:"headers"`
+}
+
+// A NetworkEvent reports progress of a request made by the page, see
+// NetworkEvents.
+type NetworkEvent struct {
+	// Method is the event name: "network.beforeRequestSent",
+	// "network.responseStarted", "network.responseCompleted" or
+	// "network.fetchError".
+	Method     string
+	Context    string
+	Navigation string
+	Timestamp  time.Time
+	Request    Request
+	// Response is nil for network.beforeRequestSent and network.fetchError.
+	Response *Response
+	// ErrorText describes the failure, for network.fetchError.
+	ErrorText string
+}
+
+// LogEntries subscribes to log.entryAdded events. Calling cancel
+// unsubscribes and closes the channel.
+func (c *Conn) LogEntries() (entries <-chan LogEntry, cancel func() error, err error) {
+	out := make(chan LogEntry)
+	cancel, err = c.forward([]string{"log.entryAdded"}, func(ev Event, stop <-chan struct{}) {
+		var e LogEntry
+		if json.Unmarshal(ev.Params, &e) == nil {
+			select {
+			case out <- e:
+			case <-stop:
+			}
+		}
+	}, func() { close(out) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, cancel, nil
+}
+
+// Loads subscribes to browsingContext.load events. Calling cancel
+// unsubscribes and closes the channel.
+func (c *Conn) Loads() (loads <-chan NavigationInfo, cancel func() error, err error) {
+	out := make(chan NavigationInfo)
+	cancel, err = c.forward([]string{"browsingContext.load"}, func(ev Event, stop <-chan struct{}) {
+		var n NavigationInfo
+		if json.Unmarshal(ev.Params, &n) == nil {
+			select {
+			case out <- n:
+			case <-stop:
+			}
+		}
+	}, func() { close(out) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, cancel, nil
+}
+
+// NetworkEvents subscribes to the events of the network module. Calling
+// cancel unsubscribes and closes the channel.
+func (c *Conn) NetworkEvents() (events <-chan NetworkEvent, cancel func() error, err error) {
+	out := make(chan NetworkEvent)
+	cancel, err = c.forward([]string{"network"}, func(ev Event, stop <-chan struct{}) {
+		var v struct {
+			Context    string    `json:"context"`
+			Navigation string    `json:"navigation"`
+			Timestamp  timestamp `json:"timestamp"`
+			Request    Request   `json:"request"`
+			Response   *Response `json:"response"`
+			ErrorText  string    `json:"errorText"`
+		}
+		if json.Unmarshal(ev.Params, &v) == nil {
+			select {
+			case out <- NetworkEvent{
+				Method:     ev.Method,
+				Context:    v.Context,
+				Navigation: v.Navigation,
+				Timestamp:  v.Timestamp.time(),
+				Request:    v.Request,
+				Response:   v.Response,
+				ErrorText:  v.ErrorText,
+			}:
+			case <-stop:
+			}
+		}
+	}, func() { close(out) })
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, cancel, nil
+}
+
+// forward subscribes to events and calls fn for each of them, then done
+// once the subscription ends, by cancel or by the connection closing. fn
+// must give up sending an event once stop is closed.
+func (c *Conn) forward(events []string, fn func(ev Event, stop <-chan struct{}), done func()) (cancel func() error, err error) {
+	ch, err := c.Subscribe(events...)
+	if err != nil {
+		return nil, err
+	}
+	stop := make(chan struct{})
+	go func() {
+		defer done()
+		for {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				fn(ev, stop)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() error {
+		once.Do(func() {
+			close(stop)
+			err = c.Unsubscribe(ch)
+		})
+		return err
+	}, nil
+}
@@ -0,0 +1,329 @@
+package selenium
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A SearchContext finds elements: a WebDriver in the page, a WebElement
+// in its descendants.
+type SearchContext interface {
+	FindElement(by, value string) (WebElement, error)
+	FindElements(by, value string) ([]WebElement, error)
+}
+
+// By locates elements. Build one with ByID, ByCSS, ByXPath, ByText or
+// Using, refine it with Then, Nth, Filter and the relative locators
+// Above, Below, LeftOf, RightOf and Near, and use it with its FindElement
+// and FindElements methods:
+//
+//	rows, err := ByCSS("table.results").Then(ByCSS("tr")).Filter(visible).FindElements(wd)
+type By struct {
+	// Using and Value are the strategy and value sent to the remote end.
+	Using, Value string
+
+	// desc describes the locator if Using and Value don't read well.
+	desc string
+	// script, if set, finds the elements instead of the remote end, see
+	// findByScript.
+	script string
+	args   []interface{}
+	// parent is the locator Then was called on.
+	parent *By
+	// nth is the index of the element to keep plus one, or 0.
+	nth       int
+	filters   []func(WebElement) bool
+	relations []relation
+}
+
+// Using locates elements with a strategy, such as ByLinkText, and value.
+func Using(by, value string) By {
+	return By{Using: by, Value: value}
+}
+
+// ByID locates elements by their id attribute.
+func ByID(id string) By {
+	// W3C remote ends don't implement the "id" strategy.
+	b := ByCSS(`[id="` + cssString(id) + `"]`)
+	b.desc = fmt.Sprintf("id %q", id)
+	return b
+}
+
+// ByCSS locates elements by CSS selector.
+func ByCSS(selector string) By {
+	return By{Using: ByCSSSelector, Value: selector}
+}
+
+// ByXPath locates elements by XPath expression. In Then, absolute
+// expressions are evaluated relative to each parent element, i.e. "//a"
+// finds the links in the parent.
+func ByXPath(expr string) By {
+	return By{Using: ByXPATH, Value: expr}
+}
+
+// ByText locates the innermost elements whose text is text, ignoring
+// leading, trailing and repeated whitespace.
+func ByText(text string) By {
+	lit := xpathLiteral(strings.Join(strings.Fields(text), " "))
+	b := ByXPath(fmt.Sprintf("//*[normalize-space(.)=%s and not(*[normalize-space(.)=%s])]", lit, lit))
+	b.desc = fmt.Sprintf("text %q", text)
+	return b
+}
+
+// Then locates the elements located by child in the elements located by
+// b.
+func (b By) Then(child By) By {
+	c := child
+	if child.parent == nil {
+		p := b
+		c.parent = &p
+	} else {
+		p := b.Then(*child.parent)
+		c.parent = &p
+	}
+	return c
+}
+
+// Nth keeps the element at index i, counting from 0, of the elements
+// located by b.
+func (b By) Nth(i int) By {
+	b.nth = i + 1
+	return b
+}
+
+// Filter keeps the elements located by b for which keep returns true.
+func (b By) Filter(keep func(WebElement) bool) By {
+	b.filters = append(b.filters[:len(b.filters):len(b.filters)], keep)
+	return b
+}
+
+func (b By) String() string {
+	s := b.desc
+	if s == "" {
+		s = fmt.Sprintf("%s %q", b.Using, b.Value)
+	}
+	if b.parent != nil {
+		s = b.parent.String() + " > " + s
+	}
+	for range b.filters {
+		s += " (filtered)"
+	}
+	for _, r := range b.relations {
+		s += " " + r.String()
+	}
+	if b.nth > 0 {
+		s += fmt.Sprintf("[%d]", b.nth-1)
+	}
+	return s
+}
+
+// simple reports whether the remote end can find the elements by itself.
+func (b *By) simple() bool {
+	return b.script == "" && b.parent == nil && b.nth == 0 && len(b.filters) == 0 && len(b.relations) == 0
+}
+
+// A FindError is returned when finding elements by a By fails.
+type FindError struct {
+	By By
+	// Err is the error returned by the remote end, or ErrNoSuchElement.
+	Err error
+}
+
+func (e *FindError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.By)
+}
+
+// ErrNoSuchElement is the Err of a FindError when no element is located.
+var ErrNoSuchElement = errors.New("no such element")
+
+// FindElement returns the first element located by b in ctx.
+func (b By) FindElement(ctx SearchContext) (WebElement, error) {
+	if b.simple() {
+		e, err := ctx.FindElement(b.Using, b.Value)
+		if err != nil {
+			return nil, &FindError{b, err}
+		}
+		return e, nil
+	}
+	elems, err := b.FindElements(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(elems) == 0 {
+		return nil, &FindError{b, ErrNoSuchElement}
+	}
+	return elems[0], nil
+}
+
+// FindElements returns the elements located by b in ctx. Unless the remote
+// end finds them by itself, the commands sent are nested in a span, see
+// TraceSpan.
+func (b By) FindElements(ctx SearchContext) ([]WebElement, error) {
+	wd, _, err := searchDriver(ctx)
+	if b.simple() || err != nil {
+		return b.findElements(ctx)
+	}
+	var elems []WebElement
+	err = TraceSpan(wd, "FindElements "+b.String(), func() error {
+		var err error
+		elems, err = b.findElements(ctx)
+		return err
+	})
+	return elems, err
+}
+
+func (b By) findElements(ctx SearchContext) ([]WebElement, error) {
+	var elems []WebElement
+	if b.parent == nil {
+		found, err := b.find(ctx, b.Value)
+		if err != nil {
+			return nil, &FindError{b, err}
+		}
+		elems = found
+	} else {
+		parents, err := b.parent.FindElements(ctx)
+		if err != nil {
+			return nil, err
+		}
+		value := b.Value
+		if b.Using == ByXPATH {
+			if value, err = relativeXPath(value); err != nil {
+				return nil, &FindError{b, err}
+			}
+		}
+		for _, p := range parents {
+			found, err := b.find(p, value)
+			if err != nil {
+				return nil, &FindError{b, err}
+			}
+			elems = append(elems, found...)
+		}
+	}
+
+	for _, keep := range b.filters {
+		var kept []WebElement
+		for _, e := range elems {
+			if keep(e) {
+				kept = append(kept, e)
+			}
+		}
+		elems = kept
+	}
+	if len(b.relations) > 0 {
+		var err error
+		if elems, err = b.keepRelated(elems); err != nil {
+			return nil, &FindError{b, err}
+		}
+	}
+	if b.nth > 0 {
+		if b.nth > len(elems) {
+			return nil, nil
+		}
+		elems = elems[b.nth-1 : b.nth]
+	}
+	return elems, nil
+}
+
+// find finds the elements matching b, but for its parent and post-filters,
+// in ctx, with value for b.Value.
+func (b *By) find(ctx SearchContext, value string) ([]WebElement, error) {
+	if b.script != "" {
+		return findByScript(ctx, b.script, b.args...)
+	}
+	return ctx.FindElements(b.Using, value)
+}
+
+// relativeXPath makes the absolute paths of expr, which may be a union
+// or a parenthesized group, relative to the context node. It returns an
+// error for paths it can't make relative, e.g. those starting with id().
+func relativeXPath(expr string) (string, error) {
+	toks, err := lexXPath(expr)
+	if err != nil {
+		return "", err
+	}
+
+	// next returns the index of the | ending the branch at toks[i], or of
+	// the ) or end of expression ending the group, and whether it is a |.
+	next := func(i int) (int, bool) {
+		depth := 0
+		for ; ; i++ {
+			switch t := toks[i]; {
+			case t.kind == xEOF:
+				return i, false
+			case t.kind == xPunct && (t.value == "(" || t.value == "["):
+				depth++
+			case t.kind == xPunct && (t.value == ")" || t.value == "]"):
+				if depth == 0 {
+					return i, false
+				}
+				depth--
+			case t.kind == xOperator && t.value == "|" && depth == 0:
+				return i, true
+			}
+		}
+	}
+	// dots are the offsets to insert a . at.
+	var dots []int
+	// group finds the absolute paths in the branches starting at toks[i],
+	// and returns the index of the token ending them.
+	var group func(i int) (int, error)
+	group = func(i int) (int, error) {
+		for {
+			switch t := toks[i]; {
+			case t.kind == xOperator && (t.value == "/" || t.value == "//"):
+				dots = append(dots, t.pos)
+			case t.kind == xPunct && t.value == "(":
+				end, err := group(i + 1)
+				if err != nil {
+					return 0, err
+				}
+				if toks[end].kind == xEOF {
+					return 0, &SelectorError{By: ByXPATH, Selector: expr, Offset: t.pos, Msg: "unclosed ("}
+				}
+				i = end + 1
+			case t.kind == xFunction || t.kind == xVariable:
+				return 0, fmt.Errorf("can't find xpath %q relative to an element: it starts with %s", expr, t)
+			}
+			end, union := next(i)
+			if !union {
+				return end, nil
+			}
+			i = end + 1
+		}
+	}
+	if _, err := group(0); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, dot := range dots {
+		b.WriteString(expr[last:dot])
+		b.WriteByte('.')
+		last = dot
+	}
+	b.WriteString(expr[last:])
+	return b.String(), nil
+}
+
+// cssString escapes s for a double-quoted CSS string.
+func cssString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `).Replace(s)
+}
+
+// xpathLiteral returns s as an XPath string literal. XPath has no escapes,
+// so strings with both kinds of quotes are built with concat().
+func xpathLiteral(s string) string {
+	if !strings.Contains(s, `"`) {
+		return `"` + s + `"`
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	parts := strings.Split(s, `"`)
+	for i, p := range parts {
+		parts[i] = `"` + p + `"`
+	}
+	return "concat(" + strings.Join(parts, `, '"', `) + ")"
+}
@@ -0,0 +1,119 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBy_String(t *testing.T) {
+	for b, want := range map[*By]string{
+		ptr(ByID("main")): `id "main"`,
+		ptr(ByCSS("ul").Then(ByXPath("li")).Nth(2)):          `css selector "ul" > xpath "li"[2]`,
+		ptr(Using(ByLinkText, "Home").Filter(nil)):           `link text "Home" (filtered)`,
+		ptr(ByCSS("a").Then(ByCSS("b").Then(ByText("c d")))): `css selector "a" > css selector "b" > text "c d"`,
+	} {
+		if got := b.String(); got != want {
+			t.Errorf("String() = %s, want %s", got, want)
+		}
+	}
+}
+
+func ptr(b By) *By {
+	return &b
+}
+
+func TestXPathLiteral(t *testing.T) {
+	for s, want := range map[string]string{
+		`plain`:         `"plain"`,
+		`say "hi"`:      `'say "hi"'`,
+		`it's "quoted"`: `concat("it's ", '"', "quoted", '"', "")`,
+	} {
+		if got := xpathLiteral(s); got != want {
+			t.Errorf("xpathLiteral(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestBy_FindElements(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var finds []string
+	find := func(w http.ResponseWriter, r *http.Request, value string) {
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		finds = append(finds, r.URL.Path+" "+v["using"]+" "+v["value"])
+		fmt.Fprintf(w, `{"status": 0, "value": %s}`, value)
+	}
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		find(w, r, `[{"ELEMENT": "ul1"}, {"ELEMENT": "ul2"}]`)
+	})
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"status": 7, "value": {"message": "not found"}}`)
+	})
+	mux.HandleFunc("/session/123/element/ul1/elements", func(w http.ResponseWriter, r *http.Request) {
+		find(w, r, `[{"ELEMENT": "li1"}, {"ELEMENT": "li2"}]`)
+	})
+	mux.HandleFunc("/session/123/element/ul2/elements", func(w http.ResponseWriter, r *http.Request) {
+		find(w, r, `[{"ELEMENT": "li3"}]`)
+	})
+
+	ids := func(elems []WebElement) []string {
+		var s []string
+		for _, e := range elems {
+			id, _ := elementID(e)
+			s = append(s, id)
+		}
+		return s
+	}
+
+	items := ByCSS("ul").Then(ByXPath("//li"))
+	elems, err := items.FindElements(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(elems), []string{"li1", "li2", "li3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got elements %v, want %v", got, want)
+	}
+	wantFinds := []string{
+		"/session/123/elements css selector ul",
+		"/session/123/element/ul1/elements xpath .//li",
+		"/session/123/element/ul2/elements xpath .//li",
+	}
+	if !reflect.DeepEqual(finds, wantFinds) {
+		t.Errorf("sent finds %q, want %q", finds, wantFinds)
+	}
+
+	finds = nil
+	if _, err := ByCSS("ul").Then(ByXPath("(//li)[1]")).FindElements(client); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/session/123/element/ul1/elements xpath (.//li)[1]"; len(finds) != 3 || finds[1] != want {
+		t.Errorf("sent finds %q, want %q second", finds, want)
+	}
+
+	notFirst := func(e WebElement) bool {
+		id, _ := elementID(e)
+		return id != "li1"
+	}
+	e, err := items.Filter(notFirst).Nth(1).FindElement(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := elementID(e); id != "li3" {
+		t.Errorf("got element %s, want li3", id)
+	}
+
+	_, err = items.Nth(5).FindElement(client)
+	if fe, ok := err.(*FindError); !ok || fe.Err != ErrNoSuchElement {
+		t.Errorf("FindElement of a missing element returned %v, want ErrNoSuchElement", err)
+	}
+	_, err = ByID("missing").FindElement(client)
+	if err == nil || err.Error() != `no such element: id "missing"` {
+		t.Errorf("FindElement returned %v, want no such element", err)
+	}
+}
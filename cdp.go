@@ -0,0 +1,149 @@
+package selenium
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// browserName returns the name of the session's browser.
+func (wd *remoteWebDriver) browserName() string {
+	if name, ok := wd.sessionCaps["browserName"].(string); ok {
+		return name
+	}
+	name, _ := wd.capabilities["browserName"].(string)
+	return name
+}
+
+func (wd *remoteWebDriver) ExecuteCDP(method string, params map[string]interface{}) (map[string]interface{}, error) {
+	// Chrome and Edge expose the DevTools Protocol under their vendor prefix.
+	var urlTemplate string
+	switch name := wd.browserName(); name {
+	case "chrome", "chromium":
+		urlTemplate = "/session/%s/goog/cdp/execute"
+	case "MicrosoftEdge", "msedge":
+		urlTemplate = "/session/%s/ms/cdp/execute"
+	default:
+		return nil, fmt.Errorf("ExecuteCDP: unsupported browser %q", name)
+	}
+
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	data, err := json.Marshal(map[string]interface{}{"cmd": method, "params": params})
+	if err != nil {
+		return nil, err
+	}
+	r, err := wd.send("POST", wd.url(urlTemplate, wd.id), data)
+	if err != nil {
+		return nil, err
+	}
+	var res map[string]interface{}
+	err = r.readValue(&res)
+	return res, err
+}
+
+// DevTools calls commonly used Chrome DevTools Protocol commands through
+// ExecuteCDP. See https://chromedevtools.github.io/devtools-protocol/.
+type DevTools struct {
+	wd WebDriver
+}
+
+// NewDevTools returns a DevTools for a Chrome or Edge session.
+func NewDevTools(wd WebDriver) *DevTools {
+	return &DevTools{wd}
+}
+
+func (d *DevTools) call(method string, params map[string]interface{}) error {
+	_, err := d.wd.ExecuteCDP(method, params)
+	return err
+}
+
+// SetExtraHTTPHeaders sends headers with every request the page makes.
+func (d *DevTools) SetExtraHTTPHeaders(headers map[string]string) error {
+	if err := d.call("Network.enable", nil); err != nil {
+		return err
+	}
+	return d.call("Network.setExtraHTTPHeaders", map[string]interface{}{"headers": headers})
+}
+
+// DeviceMetrics describes an emulated screen, see SetDeviceMetricsOverride.
+type DeviceMetrics struct {
+	Width             int     `json:"width"`
+	Height            int     `json:"height"`
+	DeviceScaleFactor float64 `json:"deviceScaleFactor"`
+	Mobile            bool    `json:"mobile"`
+}
+
+// SetDeviceMetricsOverride emulates a device screen.
+func (d *DevTools) SetDeviceMetricsOverride(m DeviceMetrics) error {
+	return d.call("Emulation.setDeviceMetricsOverride", map[string]interface{}{
+		"width":             m.Width,
+		"height":            m.Height,
+		"deviceScaleFactor": m.DeviceScaleFactor,
+		"mobile":            m.Mobile,
+	})
+}
+
+// ClearDeviceMetricsOverride stops emulating a device screen.
+func (d *DevTools) ClearDeviceMetricsOverride() error {
+	return d.call("Emulation.clearDeviceMetricsOverride", nil)
+}
+
+// NetworkConditions describes an emulated network, see
+// EmulateNetworkConditions.
+type NetworkConditions struct {
+	Offline bool
+	// Latency is added to every request.
+	Latency time.Duration
+	// DownloadThroughput and UploadThroughput are in bytes per second;
+	// -1 disables throttling.
+	DownloadThroughput int
+	UploadThroughput   int
+}
+
+// EmulateNetworkConditions throttles the browser's network.
+func (d *DevTools) EmulateNetworkConditions(c NetworkConditions) error {
+	if err := d.call("Network.enable", nil); err != nil {
+		return err
+	}
+	return d.call("Network.emulateNetworkConditions", map[string]interface{}{
+		"offline":            c.Offline,
+		"latency":            c.Latency.Seconds() * 1000,
+		"downloadThroughput": c.DownloadThroughput,
+		"uploadThroughput":   c.UploadThroughput,
+	})
+}
+
+// A Clip is a region of the page in CSS pixels, see CaptureScreenshot.
+type Clip struct {
+	X, Y, Width, Height float64
+	// Scale is the scale of the captured image, 1 if zero.
+	Scale float64
+}
+
+// CaptureScreenshot returns a PNG image of the region of the page in clip,
+// or of the viewport if clip is nil. Unlike Screenshot, the region may
+// extend beyond the viewport.
+func (d *DevTools) CaptureScreenshot(clip *Clip) (io.Reader, error) {
+	params := map[string]interface{}{"format": "png"}
+	if clip != nil {
+		scale := clip.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		params["clip"] = map[string]float64{
+			"x": clip.X, "y": clip.Y, "width": clip.Width, "height": clip.Height, "scale": scale,
+		}
+		params["captureBeyondViewport"] = true
+	}
+	res, err := d.wd.ExecuteCDP("Page.captureScreenshot", params)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := res["data"].(string)
+	return base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(data)), nil
+}
@@ -0,0 +1,98 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestExecuteCDP(t *testing.T) {
+	setup()
+	defer teardown()
+
+	for _, tc := range []struct {
+		browserName, path string
+	}{
+		{"chrome", "/session/123/goog/cdp/execute"},
+		{"MicrosoftEdge", "/session/123/ms/cdp/execute"},
+	} {
+		called := false
+		mux.HandleFunc(tc.path, func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			var v map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&v)
+			want := map[string]interface{}{
+				"cmd":    "Browser.getVersion",
+				"params": map[string]interface{}{},
+			}
+			if !reflect.DeepEqual(v, want) {
+				t.Errorf("Request body = %+v, want %+v", v, want)
+			}
+			fmt.Fprint(w, `{"value": {"product": "Chrome/120"}}`)
+		})
+
+		client.(*remoteWebDriver).sessionCaps = Capabilities{"browserName": tc.browserName}
+		res, err := client.ExecuteCDP("Browser.getVersion", nil)
+		if err != nil {
+			t.Fatalf("%s: ExecuteCDP returned error: %v", tc.browserName, err)
+		}
+		if !called {
+			t.Errorf("%s: %s not called", tc.browserName, tc.path)
+		}
+		if res["product"] != "Chrome/120" {
+			t.Errorf("%s: ExecuteCDP returned %+v", tc.browserName, res)
+		}
+	}
+
+	client.(*remoteWebDriver).sessionCaps = Capabilities{"browserName": "firefox"}
+	if _, err := client.ExecuteCDP("Browser.getVersion", nil); err == nil {
+		t.Error("ExecuteCDP on firefox: expected error")
+	}
+}
+
+func TestDevTools(t *testing.T) {
+	setup()
+	defer teardown()
+	client.(*remoteWebDriver).sessionCaps = Capabilities{"browserName": "chrome"}
+
+	var cmds []string
+	var clip interface{}
+	mux.HandleFunc("/session/123/goog/cdp/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Cmd    string
+			Params map[string]interface{}
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		cmds = append(cmds, v.Cmd)
+		if v.Cmd == "Page.captureScreenshot" {
+			clip = v.Params["clip"]
+			fmt.Fprint(w, `{"value": {"data": "iVBORw=="}}`)
+			return
+		}
+		fmt.Fprint(w, `{"value": {}}`)
+	})
+
+	d := NewDevTools(client)
+	if err := d.SetExtraHTTPHeaders(map[string]string{"X-Test": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Network.enable", "Network.setExtraHTTPHeaders"}; !reflect.DeepEqual(cmds, want) {
+		t.Errorf("sent %q, want %q", cmds, want)
+	}
+
+	img, err := d.CaptureScreenshot(&Clip{Width: 10, Height: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(img)
+	if string(data) != "\x89PNG" {
+		t.Errorf("CaptureScreenshot returned %q", data)
+	}
+	want := map[string]interface{}{"x": 0.0, "y": 0.0, "width": 10.0, "height": 20.0, "scale": 1.0}
+	if !reflect.DeepEqual(clip, want) {
+		t.Errorf("clip = %+v, want %+v", clip, want)
+	}
+}
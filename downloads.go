@@ -0,0 +1,253 @@
+package selenium
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// downloadMIMETypes are saved to disk by Firefox without asking.
+var downloadMIMETypes = []string{
+	"application/octet-stream",
+	"application/pdf",
+	"application/zip",
+	"application/json",
+	"application/vnd.ms-excel",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"text/csv",
+	"text/plain",
+}
+
+// SetDownloadDir configures the browser named by the browserName capability
+// to save downloads to dir without prompting. Chrome is configured through
+// preferences in its options, Firefox through a profile built on
+// defaultProfile and the preferences in its options.
+func (c Capabilities) SetDownloadDir(dir string) error {
+	switch c["browserName"] {
+	case "chrome":
+		key := "goog:chromeOptions"
+		if _, ok := c[key]; !ok {
+			if _, ok := c["chromeOptions"]; ok {
+				key = "chromeOptions"
+			}
+		}
+		return c.setBrowserPrefs(key, map[string]interface{}{
+			"download.default_directory":         dir,
+			"download.prompt_for_download":       false,
+			"download.directory_upgrade":         true,
+			"plugins.always_open_pdf_externally": true,
+		})
+	case "firefox":
+		prefs := map[string]interface{}{
+			"browser.download.dir":                   dir,
+			"browser.download.folderList":            2,
+			"browser.download.useDownloadDir":        true,
+			"browser.helperApps.neverAsk.saveToDisk": strings.Join(downloadMIMETypes, ","),
+			"pdfjs.disabled":                         true,
+		}
+		profile := &FirefoxProfile{}
+		for name, value := range prefs {
+			literal, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			profile.SetPreference(name, string(literal))
+		}
+		encoded, err := profile.Encode()
+		if err != nil {
+			return err
+		}
+		c["firefox_profile"] = encoded
+		return c.setBrowserPrefs("moz:firefoxOptions", prefs)
+	default:
+		return fmt.Errorf("SetDownloadDir: unsupported browser %v", c["browserName"])
+	}
+}
+
+// setBrowserPrefs merges prefs into the "prefs" map of the browser options
+// stored in c[key].
+func (c Capabilities) setBrowserPrefs(key string, prefs map[string]interface{}) error {
+	opts, _ := c[key].(map[string]interface{})
+	if opts == nil {
+		if c[key] != nil {
+			return fmt.Errorf("capability %q is not a JSON object", key)
+		}
+		opts = make(map[string]interface{})
+		c[key] = opts
+	}
+	merged, _ := opts["prefs"].(map[string]interface{})
+	if merged == nil {
+		merged = make(map[string]interface{})
+		opts["prefs"] = merged
+	}
+	for name, value := range prefs {
+		merged[name] = value
+	}
+	return nil
+}
+
+// EnableDownloads asks a Selenium 4 Grid to keep the files downloaded on
+// the node, so that they can be read with DownloadedFiles and
+// DownloadedFile.
+func (c Capabilities) EnableDownloads() {
+	c["se:downloadsEnabled"] = true
+}
+
+// Downloads lists and reads files downloaded by the browser. It is
+// implemented by WebDriver, for downloads kept by a Selenium 4 Grid, and by
+// DownloadDir, for downloads saved to a local directory.
+type Downloads interface {
+	// DownloadedFiles returns the names of the completed downloads.
+	DownloadedFiles() ([]string, error)
+	// DownloadedFile returns the contents of the named download.
+	DownloadedFile(name string) ([]byte, error)
+}
+
+// A DownloadDir is a local directory that the browser saves downloads to,
+// as set with SetDownloadDir.
+type DownloadDir string
+
+func (d DownloadDir) DownloadedFiles() ([]string, error) {
+	infos, err := ioutil.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fi := range infos {
+		if fi.Mode().IsRegular() {
+			names = append(names, fi.Name())
+		}
+	}
+	return completedDownloads(names), nil
+}
+
+func (d DownloadDir) DownloadedFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(d), filepath.Base(name)))
+}
+
+// partialDownloadSuffixes mark files that browsers are still downloading.
+var partialDownloadSuffixes = []string{".crdownload", ".part", ".partial", ".download", ".tmp"}
+
+// completedDownloads returns the sorted names that are neither partial
+// downloads nor the placeholder Firefox creates next to a ".part" file.
+func completedDownloads(names []string) []string {
+	all := make(map[string]bool, len(names))
+	for _, name := range names {
+		all[name] = true
+	}
+	completed := []string{}
+	for _, name := range names {
+		partial := all[name+".part"]
+		for _, suffix := range partialDownloadSuffixes {
+			partial = partial || strings.HasSuffix(name, suffix)
+		}
+		if !partial {
+			completed = append(completed, name)
+		}
+	}
+	sort.Strings(completed)
+	return completed
+}
+
+func (wd *remoteWebDriver) DownloadedFiles() ([]string, error) {
+	r, err := wd.send("GET", wd.url("/session/%s/se/files", wd.id), nil)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		Names []string `json:"names"`
+	}
+	if err := r.readValue(&v); err != nil {
+		return nil, err
+	}
+	return completedDownloads(v.Names), nil
+}
+
+func (wd *remoteWebDriver) DownloadedFile(name string) ([]byte, error) {
+	data, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return nil, err
+	}
+	r, err := wd.send("POST", wd.url("/session/%s/se/files", wd.id), data)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		Contents string `json:"contents"`
+	}
+	if err := r.readValue(&v); err != nil {
+		return nil, err
+	}
+
+	// The Grid returns a base64 encoded zip archive holding the file.
+	archive, err := base64.StdEncoding.DecodeString(v.Contents)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+	if len(zr.File) == 0 {
+		return nil, fmt.Errorf("DownloadedFile(%q): empty archive", name)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func (wd *remoteWebDriver) DeleteDownloadedFiles() error {
+	_, err := wd.execute("DELETE", wd.url("/session/%s/se/files", wd.id), nil)
+	return err
+}
+
+// downloadPollInterval is how often WaitForDownload lists the downloads.
+const downloadPollInterval = 200 * time.Millisecond
+
+// WaitForDownload waits up to timeout for a completed download whose name
+// satisfies match (or any download, if match is nil) and returns its name.
+// Downloads present before the call count too; delete them first to wait
+// for a new one. If d is a WebDriver, the commands sent are nested in a
+// span, see TraceSpan.
+func WaitForDownload(d Downloads, match func(name string) bool, timeout time.Duration) (string, error) {
+	wd, ok := d.(WebDriver)
+	if !ok {
+		return waitForDownload(d, match, timeout)
+	}
+	var name string
+	err := TraceSpan(wd, "WaitForDownload", func() error {
+		var err error
+		name, err = waitForDownload(d, match, timeout)
+		return err
+	})
+	return name, err
+}
+
+func waitForDownload(d Downloads, match func(name string) bool, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		names, err := d.DownloadedFiles()
+		if err != nil {
+			return "", err
+		}
+		for _, name := range names {
+			if match == nil || match(name) {
+				return name, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("no download completed after %s", timeout)
+		}
+		time.Sleep(downloadPollInterval)
+	}
+}
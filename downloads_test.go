@@ -0,0 +1,149 @@
+package selenium
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetDownloadDir_Chrome(t *testing.T) {
+	c := Capabilities{
+		"browserName":        "chrome",
+		"goog:chromeOptions": map[string]interface{}{"args": []string{"--headless"}},
+	}
+	if err := c.SetDownloadDir("/tmp/dl"); err != nil {
+		t.Fatal(err)
+	}
+	opts := c["goog:chromeOptions"].(map[string]interface{})
+	if opts["args"] == nil {
+		t.Error("SetDownloadDir dropped existing chrome options")
+	}
+	prefs := opts["prefs"].(map[string]interface{})
+	if got := prefs["download.default_directory"]; got != "/tmp/dl" {
+		t.Errorf("download.default_directory = %v, want /tmp/dl", got)
+	}
+}
+
+func TestSetDownloadDir_Firefox(t *testing.T) {
+	c := Capabilities{"browserName": "firefox"}
+	if err := c.SetDownloadDir("/tmp/dl"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(c["firefox_profile"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	userJS, _ := ioutil.ReadAll(rc)
+	for _, want := range []string{
+		`user_pref("browser.download.dir", "/tmp/dl");`,
+		`user_pref("browser.download.folderList", 2);`,
+		`user_pref("app.update.auto", false);`,
+	} {
+		if !strings.Contains(string(userJS), want) {
+			t.Errorf("user.js does not contain %s:\n%s", want, userJS)
+		}
+	}
+
+	prefs := c["moz:firefoxOptions"].(map[string]interface{})["prefs"].(map[string]interface{})
+	if got := prefs["browser.download.dir"]; got != "/tmp/dl" {
+		t.Errorf("browser.download.dir = %v, want /tmp/dl", got)
+	}
+}
+
+func TestDownloadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "selenium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.csv", "b.pdf.crdownload", "c.pdf", "c.pdf.part"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := DownloadDir(dir)
+	names, err := d.DownloadedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.csv"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("DownloadedFiles returned %q, want %q", names, want)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		os.Remove(filepath.Join(dir, "c.pdf.part"))
+	}()
+	name, err := WaitForDownload(d, func(name string) bool { return strings.HasSuffix(name, ".pdf") }, 5*time.Second)
+	if err != nil {
+		t.Fatalf("WaitForDownload returned error: %v", err)
+	}
+	if name != "c.pdf" {
+		t.Errorf("WaitForDownload returned %q, want c.pdf", name)
+	}
+	if data, err := d.DownloadedFile(name); err != nil || string(data) != "c.pdf" {
+		t.Errorf("DownloadedFile returned %q, %v", data, err)
+	}
+
+	if _, err := WaitForDownload(d, func(name string) bool { return false }, 0); err == nil {
+		t.Error("WaitForDownload with no matching file: expected error")
+	}
+}
+
+func TestDownloadedFiles_Grid(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/se/files", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"value": {"names": ["export.csv", "big.zip.crdownload"]}}`)
+		case "POST":
+			var v map[string]string
+			json.NewDecoder(r.Body).Decode(&v)
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			f, _ := zw.Create(v["name"])
+			f.Write([]byte("a,b\n"))
+			zw.Close()
+			fmt.Fprintf(w, `{"value": {"filename": %q, "contents": %q}}`, v["name"], base64.StdEncoding.EncodeToString(buf.Bytes()))
+		}
+	})
+
+	names, err := client.DownloadedFiles()
+	if err != nil {
+		t.Fatalf("DownloadedFiles returned error: %v", err)
+	}
+	if want := []string{"export.csv"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("DownloadedFiles returned %q, want %q", names, want)
+	}
+
+	data, err := client.DownloadedFile("export.csv")
+	if err != nil {
+		t.Fatalf("DownloadedFile returned error: %v", err)
+	}
+	if string(data) != "a,b\n" {
+		t.Errorf("DownloadedFile returned %q, want %q", data, "a,b\n")
+	}
+}
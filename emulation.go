@@ -0,0 +1,112 @@
+package selenium
+
+import "encoding/json"
+
+// A Location is a geographic position, see Geolocation.
+type Location struct {
+	Lat float64 `json:"latitude"`
+	Lon float64 `json:"longitude"`
+	Alt float64 `json:"altitude"`
+}
+
+// ScreenOrientation is the orientation of a mobile device's screen.
+type ScreenOrientation string
+
+/* Screen orientations */
+const (
+	Portrait  ScreenOrientation = "PORTRAIT"
+	Landscape ScreenOrientation = "LANDSCAPE"
+)
+
+// NetworkConnection is a bit mask of the enabled network connections of a
+// mobile device.
+type NetworkConnection int
+
+/* Network connections */
+const (
+	NoConnection   NetworkConnection = 0
+	AirplaneMode   NetworkConnection = 1
+	WifiConnection NetworkConnection = 2
+	DataConnection NetworkConnection = 4
+	AllConnections                   = WifiConnection | DataConnection
+)
+
+// geolocationScript replaces the page's navigator.geolocation with one that
+// reports the position given in the arguments.
+const geolocationScript = `
+var position = {
+	coords: {
+		latitude: arguments[0], longitude: arguments[1], altitude: arguments[2],
+		accuracy: 1, altitudeAccuracy: null, heading: null, speed: null
+	},
+	timestamp: Date.now()
+};
+var report = function(success) {
+	setTimeout(function() { success(position); }, 0);
+};
+navigator.geolocation.getCurrentPosition = report;
+navigator.geolocation.watchPosition = function(success) { report(success); return 0; };
+navigator.geolocation.clearWatch = function() {};
+`
+
+func (wd *remoteWebDriver) Geolocation() (loc *Location, err error) {
+	var r *reply
+	r, err = wd.send("GET", wd.url("/session/%s/location", wd.id), nil)
+	if isUnknownCommand(err) && wd.geolocation != nil {
+		l := *wd.geolocation
+		return &l, nil
+	}
+	if err == nil {
+		err = r.readValue(&loc)
+	}
+	return
+}
+
+// SetGeolocation falls back to overriding navigator.geolocation in the
+// current page when the remote end doesn't implement the location endpoint.
+// The override is lost on navigation, so it must be set again after loading
+// a page.
+func (wd *remoteWebDriver) SetGeolocation(loc Location) error {
+	err := wd.voidCommand("/session/%s/location", map[string]Location{"location": loc})
+	if !isUnknownCommand(err) {
+		return err
+	}
+	if _, err := wd.ExecuteScript(geolocationScript, []interface{}{loc.Lat, loc.Lon, loc.Alt}); err != nil {
+		return err
+	}
+	wd.geolocation = &loc
+	return nil
+}
+
+func (wd *remoteWebDriver) Orientation() (ScreenOrientation, error) {
+	o, err := wd.stringCommand("/session/%s/orientation")
+	return ScreenOrientation(o), err
+}
+
+func (wd *remoteWebDriver) SetOrientation(o ScreenOrientation) error {
+	return wd.voidCommand("/session/%s/orientation", map[string]ScreenOrientation{"orientation": o})
+}
+
+func (wd *remoteWebDriver) NetworkConnection() (c NetworkConnection, err error) {
+	var r *reply
+	if r, err = wd.send("GET", wd.url("/session/%s/network_connection", wd.id), nil); err == nil {
+		err = r.readValue(&c)
+	}
+	return
+}
+
+func (wd *remoteWebDriver) SetNetworkConnection(c NetworkConnection) (NetworkConnection, error) {
+	params := map[string]interface{}{
+		"parameters": map[string]NetworkConnection{"type": c},
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return 0, err
+	}
+	r, err := wd.send("POST", wd.url("/session/%s/network_connection", wd.id), data)
+	if err != nil {
+		return 0, err
+	}
+	err = r.readValue(&c)
+	return c, err
+}
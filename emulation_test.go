@@ -0,0 +1,93 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestSetGeolocation(t *testing.T) {
+	setup()
+	defer teardown()
+
+	want := Location{Lat: 48.8584, Lon: 2.2945, Alt: 35}
+	mux.HandleFunc("/session/123/location", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "POST":
+			var v map[string]Location
+			json.NewDecoder(r.Body).Decode(&v)
+			if v["location"] != want {
+				t.Errorf("location = %+v, want %+v", v["location"], want)
+			}
+			fmt.Fprint(w, `{"status": 0}`)
+		case "GET":
+			fmt.Fprint(w, `{"status": 0, "value": {"latitude": 48.8584, "longitude": 2.2945, "altitude": 35}}`)
+		}
+	})
+
+	if err := client.SetGeolocation(want); err != nil {
+		t.Fatalf("SetGeolocation returned error: %v", err)
+	}
+	loc, err := client.Geolocation()
+	if err != nil {
+		t.Fatalf("Geolocation returned error: %v", err)
+	}
+	if *loc != want {
+		t.Errorf("Geolocation returned %+v, want %+v", loc, want)
+	}
+}
+
+func TestSetGeolocation_ScriptFallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var args []interface{}
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct{ Args []interface{} }
+		json.NewDecoder(r.Body).Decode(&v)
+		args = v.Args
+		fmt.Fprint(w, `{"status": 0, "value": null}`)
+	})
+
+	want := Location{Lat: 1, Lon: 2, Alt: 3}
+	if err := client.SetGeolocation(want); err != nil {
+		t.Fatalf("SetGeolocation returned error: %v", err)
+	}
+	if !reflect.DeepEqual(args, []interface{}{1.0, 2.0, 3.0}) {
+		t.Errorf("script args = %+v, want [1 2 3]", args)
+	}
+	loc, err := client.Geolocation()
+	if err != nil {
+		t.Fatalf("Geolocation returned error: %v", err)
+	}
+	if *loc != want {
+		t.Errorf("Geolocation returned %+v, want %+v", loc, want)
+	}
+}
+
+func TestSetNetworkConnection(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/network_connection", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var v struct {
+			Parameters struct{ Type int }
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		if v.Parameters.Type != int(AirplaneMode) {
+			t.Errorf("type = %d, want %d", v.Parameters.Type, AirplaneMode)
+		}
+		fmt.Fprint(w, `{"status": 0, "value": 1}`)
+	})
+
+	c, err := client.SetNetworkConnection(AirplaneMode)
+	if err != nil {
+		t.Fatalf("SetNetworkConnection returned error: %v", err)
+	}
+	if c != AirplaneMode {
+		t.Errorf("SetNetworkConnection returned %d, want %d", c, AirplaneMode)
+	}
+}
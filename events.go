@@ -0,0 +1,251 @@
+package selenium
+
+// A Listener is notified of the commands sent through a WebDriver returned
+// by NewEventFiringWebDriver, and of the elements it finds. Embed
+// NoopListener to implement only some of the methods.
+type Listener interface {
+	// BeforeNavigate and AfterNavigate are called around Get, with the URL
+	// opened, and around Back, Forward and Refresh, with an empty URL.
+	BeforeNavigate(wd WebDriver, url string)
+	AfterNavigate(wd WebDriver, url string)
+	// BeforeFind and AfterFind are called around the finds of wd, with a
+	// nil parent, and of its elements. AfterFind gets the elements found.
+	BeforeFind(wd WebDriver, parent WebElement, by, value string)
+	AfterFind(wd WebDriver, parent WebElement, by, value string, found []WebElement)
+	// BeforeClick and AfterClick are called around clicks on elements.
+	BeforeClick(wd WebDriver, elem WebElement)
+	AfterClick(wd WebDriver, elem WebElement)
+	// BeforeChangeValue and AfterChangeValue are called around SendKeys,
+	// with the keys sent, and Clear, with no keys.
+	BeforeChangeValue(wd WebDriver, elem WebElement, keys string)
+	AfterChangeValue(wd WebDriver, elem WebElement, keys string)
+	// BeforeScript and AfterScript are called around ExecuteScript and
+	// ExecuteScriptAsync.
+	BeforeScript(wd WebDriver, script string)
+	AfterScript(wd WebDriver, script string)
+	// OnException is called when one of the commands above fails, instead
+	// of its After method.
+	OnException(wd WebDriver, err error)
+}
+
+// NoopListener is a Listener that does nothing.
+type NoopListener struct{}
+
+func (NoopListener) BeforeNavigate(wd WebDriver, url string)                      {}
+func (NoopListener) AfterNavigate(wd WebDriver, url string)                       {}
+func (NoopListener) BeforeFind(wd WebDriver, parent WebElement, by, value string) {}
+func (NoopListener) BeforeClick(wd WebDriver, elem WebElement)                    {}
+func (NoopListener) AfterClick(wd WebDriver, elem WebElement)                     {}
+func (NoopListener) BeforeChangeValue(wd WebDriver, elem WebElement, keys string) {}
+func (NoopListener) AfterChangeValue(wd WebDriver, elem WebElement, keys string)  {}
+func (NoopListener) BeforeScript(wd WebDriver, script string)                     {}
+func (NoopListener) AfterScript(wd WebDriver, script string)                      {}
+func (NoopListener) OnException(wd WebDriver, err error)                          {}
+
+func (NoopListener) AfterFind(wd WebDriver, parent WebElement, by, value string, found []WebElement) {
+}
+
+// WrapsDriver is implemented by WebDrivers that decorate another one.
+type WrapsDriver interface {
+	WrappedDriver() WebDriver
+}
+
+// WrapsElement is implemented by WebElements that decorate another one.
+// The package accepts them wherever it accepts its own elements, e.g. as
+// script arguments.
+type WrapsElement interface {
+	WrappedElement() WebElement
+}
+
+// unwrapElement returns the innermost element wrapped by e.
+func unwrapElement(e WebElement) WebElement {
+	for {
+		w, ok := e.(WrapsElement)
+		if !ok {
+			return e
+		}
+		e = w.WrappedElement()
+	}
+}
+
+// NewEventFiringWebDriver returns a WebDriver that sends its commands
+// through wd and notifies l of them. The elements it finds notify l too.
+func NewEventFiringWebDriver(wd WebDriver, l Listener) WebDriver {
+	return &eventFiringDriver{WebDriver: wd, l: l}
+}
+
+type eventFiringDriver struct {
+	WebDriver
+	l Listener
+}
+
+func (d *eventFiringDriver) WrappedDriver() WebDriver {
+	return d.WebDriver
+}
+
+// fail notifies the listener of err, if not nil, and returns it.
+func (d *eventFiringDriver) fail(err error) error {
+	if err != nil {
+		d.l.OnException(d, err)
+	}
+	return err
+}
+
+func (d *eventFiringDriver) navigate(url string, fn func() error) error {
+	d.l.BeforeNavigate(d, url)
+	if err := fn(); err != nil {
+		return d.fail(err)
+	}
+	d.l.AfterNavigate(d, url)
+	return nil
+}
+
+func (d *eventFiringDriver) Get(url string) error {
+	return d.navigate(url, func() error { return d.WebDriver.Get(url) })
+}
+
+func (d *eventFiringDriver) Back() error {
+	return d.navigate("", d.WebDriver.Back)
+}
+
+func (d *eventFiringDriver) Forward() error {
+	return d.navigate("", d.WebDriver.Forward)
+}
+
+func (d *eventFiringDriver) Refresh() error {
+	return d.navigate("", d.WebDriver.Refresh)
+}
+
+// find notifies the listener of a find of elements by fn and wraps them.
+func (d *eventFiringDriver) find(parent WebElement, by, value string, fn func() ([]WebElement, error)) ([]WebElement, error) {
+	d.l.BeforeFind(d, parent, by, value)
+	elems, err := fn()
+	if err != nil {
+		return nil, d.fail(err)
+	}
+	for i, e := range elems {
+		elems[i] = d.wrap(e)
+	}
+	d.l.AfterFind(d, parent, by, value, elems)
+	return elems, nil
+}
+
+// findOne is like find for a single element.
+func (d *eventFiringDriver) findOne(parent WebElement, by, value string, fn func() (WebElement, error)) (WebElement, error) {
+	elems, err := d.find(parent, by, value, func() ([]WebElement, error) {
+		e, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return []WebElement{e}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return elems[0], nil
+}
+
+func (d *eventFiringDriver) wrap(e WebElement) WebElement {
+	return &eventFiringElement{WebElement: e, d: d}
+}
+
+func (d *eventFiringDriver) FindElement(by, value string) (WebElement, error) {
+	return d.findOne(nil, by, value, func() (WebElement, error) { return d.WebDriver.FindElement(by, value) })
+}
+
+func (d *eventFiringDriver) FindElements(by, value string) ([]WebElement, error) {
+	return d.find(nil, by, value, func() ([]WebElement, error) { return d.WebDriver.FindElements(by, value) })
+}
+
+func (d *eventFiringDriver) Q(sel string) (WebElement, error) {
+	return d.FindElement(ByCSSSelector, sel)
+}
+
+func (d *eventFiringDriver) QAll(sel string) ([]WebElement, error) {
+	return d.FindElements(ByCSSSelector, sel)
+}
+
+func (d *eventFiringDriver) ActiveElement() (WebElement, error) {
+	e, err := d.WebDriver.ActiveElement()
+	if err != nil {
+		return nil, err
+	}
+	return d.wrap(e), nil
+}
+
+func (d *eventFiringDriver) script(script string, fn func() (interface{}, error)) (interface{}, error) {
+	d.l.BeforeScript(d, script)
+	res, err := fn()
+	if err != nil {
+		return nil, d.fail(err)
+	}
+	d.l.AfterScript(d, script)
+	return res, nil
+}
+
+func (d *eventFiringDriver) ExecuteScript(script string, args []interface{}) (interface{}, error) {
+	return d.script(script, func() (interface{}, error) { return d.WebDriver.ExecuteScript(script, args) })
+}
+
+func (d *eventFiringDriver) ExecuteScriptAsync(script string, args []interface{}) (interface{}, error) {
+	return d.script(script, func() (interface{}, error) { return d.WebDriver.ExecuteScriptAsync(script, args) })
+}
+
+func (d *eventFiringDriver) T(t TestingT) WebDriverT {
+	return &webDriverT{d, t}
+}
+
+type eventFiringElement struct {
+	WebElement
+	d *eventFiringDriver
+}
+
+func (e *eventFiringElement) WrappedElement() WebElement {
+	return e.WebElement
+}
+
+func (e *eventFiringElement) Click() error {
+	e.d.l.BeforeClick(e.d, e)
+	if err := e.WebElement.Click(); err != nil {
+		return e.d.fail(err)
+	}
+	e.d.l.AfterClick(e.d, e)
+	return nil
+}
+
+func (e *eventFiringElement) changeValue(keys string, fn func() error) error {
+	e.d.l.BeforeChangeValue(e.d, e, keys)
+	if err := fn(); err != nil {
+		return e.d.fail(err)
+	}
+	e.d.l.AfterChangeValue(e.d, e, keys)
+	return nil
+}
+
+func (e *eventFiringElement) SendKeys(keys string) error {
+	return e.changeValue(keys, func() error { return e.WebElement.SendKeys(keys) })
+}
+
+func (e *eventFiringElement) Clear() error {
+	return e.changeValue("", e.WebElement.Clear)
+}
+
+func (e *eventFiringElement) FindElement(by, value string) (WebElement, error) {
+	return e.d.findOne(e, by, value, func() (WebElement, error) { return e.WebElement.FindElement(by, value) })
+}
+
+func (e *eventFiringElement) FindElements(by, value string) ([]WebElement, error) {
+	return e.d.find(e, by, value, func() ([]WebElement, error) { return e.WebElement.FindElements(by, value) })
+}
+
+func (e *eventFiringElement) Q(sel string) (WebElement, error) {
+	return e.FindElement(ByCSSSelector, sel)
+}
+
+func (e *eventFiringElement) QAll(sel string) ([]WebElement, error) {
+	return e.FindElements(ByCSSSelector, sel)
+}
+
+func (e *eventFiringElement) T(t TestingT) WebElementT {
+	return &webElementT{e, t}
+}
@@ -0,0 +1,156 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// recordingListener records the events it is notified of.
+type recordingListener struct {
+	NoopListener
+	events []string
+}
+
+func (l *recordingListener) record(format string, args ...interface{}) {
+	l.events = append(l.events, fmt.Sprintf(format, args...))
+}
+
+func (l *recordingListener) BeforeNavigate(wd WebDriver, url string) {
+	l.record("BeforeNavigate %s", url)
+}
+
+func (l *recordingListener) AfterNavigate(wd WebDriver, url string) {
+	l.record("AfterNavigate %s", url)
+}
+
+func (l *recordingListener) BeforeFind(wd WebDriver, parent WebElement, by, value string) {
+	l.record("BeforeFind %v %s", parent != nil, value)
+}
+
+func (l *recordingListener) AfterFind(wd WebDriver, parent WebElement, by, value string, found []WebElement) {
+	l.record("AfterFind %v %s %d", parent != nil, value, len(found))
+}
+
+func (l *recordingListener) BeforeClick(wd WebDriver, elem WebElement) {
+	l.record("BeforeClick")
+}
+
+func (l *recordingListener) AfterClick(wd WebDriver, elem WebElement) {
+	l.record("AfterClick")
+}
+
+func (l *recordingListener) BeforeChangeValue(wd WebDriver, elem WebElement, keys string) {
+	l.record("BeforeChangeValue %q", keys)
+}
+
+func (l *recordingListener) AfterChangeValue(wd WebDriver, elem WebElement, keys string) {
+	l.record("AfterChangeValue %q", keys)
+}
+
+func (l *recordingListener) BeforeScript(wd WebDriver, script string) {
+	l.record("BeforeScript %s", script)
+}
+
+func (l *recordingListener) AfterScript(wd WebDriver, script string) {
+	l.record("AfterScript %s", script)
+}
+
+func (l *recordingListener) OnException(wd WebDriver, err error) {
+	l.record("OnException %s", err)
+}
+
+func TestEventFiringWebDriver(t *testing.T) {
+	setup()
+	defer teardown()
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0}`)
+	}
+	mux.HandleFunc("/session/123/url", ok)
+	mux.HandleFunc("/session/123/back", ok)
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "form"}}`)
+	})
+	mux.HandleFunc("/session/123/element/form/elements", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "a"}, {"ELEMENT": "b"}]}`)
+	})
+	mux.HandleFunc("/session/123/element/a/click", ok)
+	mux.HandleFunc("/session/123/element/a/value", ok)
+	mux.HandleFunc("/session/123/element/a/clear", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"status": 12}`)
+	})
+	var scriptArgs []interface{}
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct{ Args []interface{} }
+		json.NewDecoder(r.Body).Decode(&v)
+		scriptArgs = v.Args
+		fmt.Fprint(w, `{"status": 0, "value": null}`)
+	})
+
+	l := &recordingListener{}
+	wd := NewEventFiringWebDriver(client, l)
+	if wd.(WrapsDriver).WrappedDriver() != client {
+		t.Error("WrappedDriver did not return the wrapped driver")
+	}
+
+	if err := wd.Get("http://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wd.Back(); err != nil {
+		t.Fatal(err)
+	}
+	form, err := wd.Q("form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, err := form.QAll("input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inputs[0].Click(); err != nil {
+		t.Fatal(err)
+	}
+	if err := inputs[0].SendKeys("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := inputs[0].Clear(); err == nil {
+		t.Error("Clear returned no error")
+	}
+	if _, err := wd.ExecuteScript("return 1", []interface{}{inputs[1]}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"BeforeNavigate http://example.com/",
+		"AfterNavigate http://example.com/",
+		"BeforeNavigate ",
+		"AfterNavigate ",
+		"BeforeFind false form",
+		"AfterFind false form 1",
+		"BeforeFind true input",
+		"AfterFind true input 2",
+		"BeforeClick",
+		"AfterClick",
+		`BeforeChangeValue "hi"`,
+		`AfterChangeValue "hi"`,
+		`BeforeChangeValue ""`,
+		"OnException invalid element state",
+		"BeforeScript return 1",
+		"AfterScript return 1",
+	}
+	if !reflect.DeepEqual(l.events, want) {
+		t.Errorf("got events\n%q\nwant\n%q", l.events, want)
+	}
+
+	wantArgs := []interface{}{map[string]interface{}{"ELEMENT": "b"}}
+	if !reflect.DeepEqual(scriptArgs, wantArgs) {
+		t.Errorf("got script args %v, want %v", scriptArgs, wantArgs)
+	}
+	if id, err := elementID(inputs[1]); err != nil || id != "b" {
+		t.Errorf("elementID of a wrapped element returned %q, %v, want b", id, err)
+	}
+}
@@ -1,5 +1,16 @@
 package selenium
 
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
 var defaultProfile = map[string]string{
 	"app.update.auto":                           "false",
 	"app.update.enabled":                        "false",
@@ -46,6 +57,81 @@ var defaultProfile = map[string]string{
 	"webdriver_enable_native_events":            "true",
 }
 
+// A FirefoxProfile is a Firefox profile to start the browser with, sent as
+// the firefox_profile capability.
 type FirefoxProfile struct {
+	// Root is the directory holding the profile files, if any.
 	Root string
+	// Prefs holds preferences set in addition to defaultProfile. Values are
+	// JavaScript literals, e.g. "true", "2" or "\"about:blank\"".
+	Prefs map[string]string
+}
+
+// SetPreference sets the preference name to value, a JavaScript literal.
+func (p *FirefoxProfile) SetPreference(name, value string) {
+	if p.Prefs == nil {
+		p.Prefs = make(map[string]string)
+	}
+	p.Prefs[name] = value
+}
+
+// Encode returns the profile as a base64 encoded zip archive, the format
+// expected in the firefox_profile capability. The archive holds the files
+// under Root and a user.js setting defaultProfile overridden by Prefs.
+func (p *FirefoxProfile) Encode() (string, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	if p.Root != "" {
+		err := filepath.Walk(p.Root, func(path string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			name, err := filepath.Rel(p.Root, path)
+			if err != nil || name == "user.js" {
+				return err
+			}
+			w, err := zw.Create(filepath.ToSlash(name))
+			if err != nil {
+				return err
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(w, f)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	prefs := make(map[string]string, len(defaultProfile)+len(p.Prefs))
+	for name, value := range defaultProfile {
+		prefs[name] = value
+	}
+	for name, value := range p.Prefs {
+		prefs[name] = value
+	}
+	names := make([]string, 0, len(prefs))
+	for name := range prefs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w, err := zw.Create("user.js")
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "user_pref(%q, %s);\n", name, prefs[name]); err != nil {
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
 }
@@ -0,0 +1,291 @@
+// Package grid is a client for the administration API of a Selenium 4
+// Grid hub: its status, nodes and slots, node draining and the removal of
+// leaked sessions.
+//
+//	g := grid.NewClient("http://localhost:4444/wd/hub")
+//	if err := g.WaitForCapacity("chrome", 4, time.Minute); err != nil {
+//		...
+//	}
+//
+// See https://www.selenium.dev/documentation/grid/advanced_features/endpoints/.
+package grid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often WaitForCapacity polls the hub.
+var pollInterval = time.Second
+
+// A Client talks to a Selenium Grid hub.
+type Client struct {
+	// URL is the base URL of the hub, e.g. "http://localhost:4444".
+	URL string
+	// RegistrationSecret is sent with the commands that change the state of
+	// nodes, if the grid was started with --registration-secret.
+	RegistrationSecret string
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the hub at url. url may be the executor
+// URL given to selenium.NewRemote, including its "/wd/hub" path.
+func NewClient(url string) *Client {
+	url = strings.TrimSuffix(url, "/")
+	url = strings.TrimSuffix(url, "/wd/hub")
+	return &Client{URL: url}
+}
+
+// Status is the state of the grid.
+type Status struct {
+	// Ready is whether the grid can accept new sessions.
+	Ready   bool   `json:"ready"`
+	Message string `json:"message"`
+	Nodes   []Node `json:"nodes"`
+}
+
+// A Node is a machine registered with the grid that runs sessions.
+type Node struct {
+	ID  string `json:"id"`
+	URI string `json:"uri"`
+	// Availability is "UP", "DRAINING" or "DOWN".
+	Availability string `json:"availability"`
+	Version      string `json:"version"`
+	MaxSessions  int    `json:"maxSessions"`
+	OSInfo       OSInfo `json:"osInfo"`
+	Slots        []Slot `json:"slots"`
+}
+
+// OSInfo describes the operating system of a Node.
+type OSInfo struct {
+	Arch    string `json:"arch"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// A Slot can run one session with the capabilities of its stereotype.
+type Slot struct {
+	ID         SlotID                 `json:"id"`
+	Stereotype map[string]interface{} `json:"stereotype"`
+	// Session is the session running in the slot, or nil if the slot is
+	// free.
+	Session     *Session `json:"session"`
+	LastStarted string   `json:"lastStarted"`
+}
+
+// SlotID identifies a Slot.
+type SlotID struct {
+	HostID string `json:"hostId"`
+	ID     string `json:"id"`
+}
+
+// A Session is a session running in a Slot.
+type Session struct {
+	ID           string                 `json:"sessionId"`
+	Start        string                 `json:"start"`
+	Capabilities map[string]interface{} `json:"capabilities"`
+}
+
+// matches reports whether the slot runs sessions of browserName, or of
+// any browser if browserName is empty.
+func (s *Slot) matches(browserName string) bool {
+	return browserName == "" || s.Stereotype["browserName"] == browserName
+}
+
+// FreeSlots returns the number of free slots for browserName, or for any
+// browser if browserName is empty, on the nodes that are up.
+func (s *Status) FreeSlots(browserName string) int {
+	n := 0
+	for _, node := range s.Nodes {
+		if node.Availability != "UP" {
+			continue
+		}
+		for _, slot := range node.Slots {
+			if slot.Session == nil && slot.matches(browserName) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Status returns the state of the grid.
+func (c *Client) Status() (*Status, error) {
+	var v struct {
+		Value Status `json:"value"`
+	}
+	if err := c.do("GET", "/status", nil, &v); err != nil {
+		return nil, err
+	}
+	return &v.Value, nil
+}
+
+// Ready reports whether the grid can accept new sessions.
+func (c *Client) Ready() (bool, error) {
+	s, err := c.Status()
+	if err != nil {
+		return false, err
+	}
+	return s.Ready, nil
+}
+
+// Nodes returns the nodes registered with the grid.
+func (c *Client) Nodes() ([]Node, error) {
+	s, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+	return s.Nodes, nil
+}
+
+// Usage summarizes how busy the grid is.
+type Usage struct {
+	NodeCount  int `json:"nodeCount"`
+	TotalSlots int `json:"totalSlots"`
+	// SessionCount is the number of slots in use.
+	SessionCount int `json:"sessionCount"`
+	MaxSession   int `json:"maxSession"`
+	// QueueSize is the number of session requests waiting for a slot.
+	QueueSize int `json:"sessionQueueSize"`
+}
+
+// Usage returns the slot usage of the grid.
+func (c *Client) Usage() (*Usage, error) {
+	var v struct {
+		Grid Usage `json:"grid"`
+	}
+	err := c.Query("{ grid { nodeCount totalSlots sessionCount maxSession sessionQueueSize } }", nil, &v)
+	if err != nil {
+		return nil, err
+	}
+	return &v.Grid, nil
+}
+
+// SessionNode returns the node running the session with the given ID.
+func (c *Client) SessionNode(sessionID string) (*Node, error) {
+	nodes, err := c.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range nodes {
+		for _, slot := range node.Slots {
+			if slot.Session != nil && slot.Session.ID == sessionID {
+				return &nodes[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("grid: session %s not found", sessionID)
+}
+
+// WaitForCapacity waits until the grid is ready and has at least n free
+// slots for browserName, or for any browser if browserName is empty.
+func (c *Client) WaitForCapacity(browserName string, n int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		s, err := c.Status()
+		if err != nil {
+			return err
+		}
+		free := s.FreeSlots(browserName)
+		if s.Ready && free >= n {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("grid: %d free slots after %s, want %d", free, timeout, n)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Drain stops the node with the given ID from accepting new sessions. The
+// node shuts down once its running sessions end.
+func (c *Client) Drain(nodeID string) error {
+	return c.do("POST", "/se/grid/distributor/node/"+nodeID+"/drain", nil, nil)
+}
+
+// DeleteSession ends the session with the given ID on the node running it,
+// freeing its slot. It works for sessions whose client is gone.
+func (c *Client) DeleteSession(sessionID string) error {
+	node, err := c.SessionNode(sessionID)
+	if err != nil {
+		return err
+	}
+	return c.do("DELETE", strings.TrimSuffix(node.URI, "/")+"/se/grid/node/session/"+sessionID, nil, nil)
+}
+
+// Query runs a GraphQL query against the grid and decodes the data of the
+// result into result.
+func (c *Client) Query(query string, variables map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	var v struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := c.do("POST", "/graphql", body, &v); err != nil {
+		return err
+	}
+	if len(v.Errors) > 0 {
+		return fmt.Errorf("grid: graphql: %s", v.Errors[0].Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(v.Data, result)
+}
+
+// do sends a request to path, which is relative to the hub unless it is an
+// absolute URL, and decodes the JSON reply into result, unless it is nil.
+func (c *Client) do(method, path string, body []byte, result interface{}) error {
+	url := path
+	if !strings.Contains(path, "://") {
+		url = c.URL + path
+	}
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.RegistrationSecret != "" {
+		req.Header.Set("X-REGISTRATION-SECRET", c.RegistrationSecret)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("grid: %s %s: %s: %s", method, path, res.Status, bytes.TrimSpace(buf))
+	}
+	if result == nil || len(buf) == 0 {
+		return nil
+	}
+	return json.Unmarshal(buf, result)
+}
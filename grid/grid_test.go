@@ -0,0 +1,180 @@
+package grid
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const statusJSON = `{"value": {
+	"ready": %t,
+	"message": "Selenium Grid ready.",
+	"nodes": [{
+		"id": "node-1",
+		"uri": %q,
+		"availability": "UP",
+		"maxSessions": 2,
+		"version": "4.8.0",
+		"osInfo": {"arch": "amd64", "name": "Linux", "version": "5.15"},
+		"slots": [
+			{"id": {"hostId": "node-1", "id": "slot-1"}, "stereotype": {"browserName": "chrome"},
+			 "session": {"sessionId": "abc", "start": "2023-01-01T00:00:00Z", "capabilities": {"browserName": "chrome"}}},
+			{"id": {"hostId": "node-1", "id": "slot-2"}, "stereotype": {"browserName": "chrome"}, "session": null},
+			{"id": {"hostId": "node-1", "id": "slot-3"}, "stereotype": {"browserName": "firefox"}, "session": null}
+		]
+	}, {
+		"id": "node-2",
+		"uri": "http://10.0.0.3:5555",
+		"availability": "DRAINING",
+		"slots": [{"id": {"hostId": "node-2", "id": "slot-1"}, "stereotype": {"browserName": "chrome"}, "session": null}]
+	}]
+}}`
+
+type hub struct {
+	*httptest.Server
+	mu       sync.Mutex
+	ready    bool
+	requests []string
+}
+
+func newHub() *hub {
+	h := &hub{ready: true}
+	h.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.mu.Lock()
+		h.requests = append(h.requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-REGISTRATION-SECRET"))
+		ready := h.ready
+		h.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/status":
+			fmt.Fprintf(w, statusJSON, ready, h.URL)
+		case "/graphql":
+			var q struct{ Query string }
+			body, _ := ioutil.ReadAll(r.Body)
+			json.Unmarshal(body, &q)
+			if q.Query == "bogus" {
+				fmt.Fprint(w, `{"errors": [{"message": "invalid query"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"data": {"grid": {"nodeCount": 2, "totalSlots": 4, "sessionCount": 1, "maxSession": 4, "sessionQueueSize": 3}}}`)
+		case "/se/grid/distributor/node/node-1/drain", "/se/grid/node/session/abc":
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	return h
+}
+
+func (h *hub) lastRequest() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests[len(h.requests)-1]
+}
+
+func TestNewClient(t *testing.T) {
+	for _, url := range []string{"http://hub:4444/wd/hub", "http://hub:4444/wd/hub/", "http://hub:4444"} {
+		if got := NewClient(url).URL; got != "http://hub:4444" {
+			t.Errorf("NewClient(%q).URL = %q, want http://hub:4444", url, got)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+	c := NewClient(h.URL + "/wd/hub")
+
+	s, err := c.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Ready || len(s.Nodes) != 2 {
+		t.Fatalf("got status %+v", s)
+	}
+	n := s.Nodes[0]
+	if n.ID != "node-1" || n.OSInfo.Name != "Linux" || len(n.Slots) != 3 || n.Slots[0].Session.ID != "abc" {
+		t.Errorf("got node %+v", n)
+	}
+	for browser, want := range map[string]int{"": 2, "chrome": 1, "firefox": 1, "safari": 0} {
+		if got := s.FreeSlots(browser); got != want {
+			t.Errorf("FreeSlots(%q) = %d, want %d", browser, got, want)
+		}
+	}
+
+	node, err := c.SessionNode("abc")
+	if err != nil || node.ID != "node-1" {
+		t.Errorf("SessionNode returned %v, %v, want node-1", node, err)
+	}
+	if _, err := c.SessionNode("missing"); err == nil {
+		t.Error("SessionNode of a missing session returned no error")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+	c := NewClient(h.URL)
+
+	u, err := c.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Usage{NodeCount: 2, TotalSlots: 4, SessionCount: 1, MaxSession: 4, QueueSize: 3}); *u != want {
+		t.Errorf("got usage %+v, want %+v", *u, want)
+	}
+	if err := c.Query("bogus", nil, nil); err == nil || err.Error() != "grid: graphql: invalid query" {
+		t.Errorf("got error %v, want graphql error", err)
+	}
+}
+
+func TestWaitForCapacity(t *testing.T) {
+	defer func(d time.Duration) { pollInterval = d }(pollInterval)
+	pollInterval = 10 * time.Millisecond
+
+	h := newHub()
+	defer h.Close()
+	c := NewClient(h.URL)
+
+	h.ready = false
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		h.mu.Lock()
+		h.ready = true
+		h.mu.Unlock()
+	}()
+	if err := c.WaitForCapacity("chrome", 1, 5*time.Second); err != nil {
+		t.Errorf("WaitForCapacity returned error: %v", err)
+	}
+	if err := c.WaitForCapacity("chrome", 2, 30*time.Millisecond); err == nil {
+		t.Error("WaitForCapacity for more slots than the grid has returned no error")
+	}
+}
+
+func TestDrainAndDeleteSession(t *testing.T) {
+	h := newHub()
+	defer h.Close()
+	c := NewClient(h.URL)
+	c.RegistrationSecret = "s3cret"
+
+	if err := c.Drain("node-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.lastRequest(), "POST /se/grid/distributor/node/node-1/drain s3cret"; got != want {
+		t.Errorf("got request %q, want %q", got, want)
+	}
+	if err := c.DeleteSession("abc"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.lastRequest(), "DELETE /se/grid/node/session/abc s3cret"; got != want {
+		t.Errorf("got request %q, want %q", got, want)
+	}
+	if err := c.Drain("node-9"); err == nil {
+		t.Error("Drain of an unknown node returned no error")
+	}
+}
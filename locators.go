@@ -0,0 +1,244 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTestIDAttribute is the attribute ByTestID locates elements by.
+const DefaultTestIDAttribute = "data-testid"
+
+// ByTextContaining locates the innermost elements whose text contains
+// text, ignoring leading, trailing and repeated whitespace.
+func ByTextContaining(text string) By {
+	lit := xpathLiteral(strings.Join(strings.Fields(text), " "))
+	b := ByXPath(fmt.Sprintf("//*[contains(normalize-space(.), %s) and not(*[contains(normalize-space(.), %s)])]", lit, lit))
+	b.desc = fmt.Sprintf("text containing %q", text)
+	return b
+}
+
+// ByTextMatching locates the innermost elements whose text, with
+// whitespace collapsed, matches re. re must also be valid JavaScript
+// regular expression syntax; leading (?i), (?m) and (?s) flags are
+// supported.
+func ByTextMatching(re *regexp.Regexp) By {
+	source, flags := jsRegexp(re)
+	return By{
+		desc:   fmt.Sprintf("text matching /%s/%s", source, flags),
+		script: textMatchingScript,
+		args:   []interface{}{source, flags},
+	}
+}
+
+// ByLabel locates the form controls labelled text, by a <label> element
+// or an aria-label attribute.
+func ByLabel(text string) By {
+	lit := xpathLiteral(strings.Join(strings.Fields(text), " "))
+	label := fmt.Sprintf("//label[normalize-space(.)=%s]", lit)
+	b := ByXPath(fmt.Sprintf("//*[@id=%s/@for] | %s//*[self::input or self::select or self::textarea] | //*[@aria-label=%s]", label, label, lit))
+	b.desc = fmt.Sprintf("label %q", text)
+	return b
+}
+
+// ByRole locates the visible elements with an ARIA role, given by their
+// role attribute or implied by their tag, e.g. "button" or "link". Unless
+// name is empty, their accessible name must be name, e.g. the text of a
+// button or link or the label of a form control.
+func ByRole(role, name string) By {
+	b := By{
+		desc:   fmt.Sprintf("role %q", role),
+		script: roleScript,
+		args:   []interface{}{role, nil},
+	}
+	if name != "" {
+		b.desc += fmt.Sprintf(" named %q", name)
+		b.args[1] = name
+	}
+	return b
+}
+
+// ByPlaceholder locates the elements whose placeholder is text.
+func ByPlaceholder(text string) By {
+	b := ByCSS(`[placeholder="` + cssString(text) + `"]`)
+	b.desc = fmt.Sprintf("placeholder %q", text)
+	return b
+}
+
+// ByTestID locates the elements whose DefaultTestIDAttribute is id.
+func ByTestID(id string) By {
+	b := ByTestIDAttr(DefaultTestIDAttribute, id)
+	b.desc = fmt.Sprintf("test ID %q", id)
+	return b
+}
+
+// ByTestIDAttr locates the elements whose test ID attribute attr, e.g.
+// "data-qa", is id.
+func ByTestIDAttr(attr, id string) By {
+	b := ByCSS(`[` + attr + `="` + cssString(id) + `"]`)
+	b.desc = fmt.Sprintf("%s %q", attr, id)
+	return b
+}
+
+// jsRegexp returns the JavaScript source and flags of re.
+func jsRegexp(re *regexp.Regexp) (source, flags string) {
+	source = re.String()
+	if m := regexp.MustCompile(`^\(\?([ims]+)\)`).FindStringSubmatch(source); m != nil {
+		source, flags = source[len(m[0]):], m[1]
+	}
+	return source, flags
+}
+
+// searchDriver returns the remote WebDriver of ctx, and the element ctx
+// is, if any.
+func searchDriver(ctx SearchContext) (*remoteWebDriver, *remoteWE, error) {
+	switch c := ctx.(type) {
+	case WebElement:
+		e, ok := unwrapElement(c).(*remoteWE)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported WebElement implementation %T", c)
+		}
+		return e.parent, e, nil
+	case WebDriver:
+		for {
+			w, ok := c.(WrapsDriver)
+			if !ok {
+				break
+			}
+			c = w.WrappedDriver()
+		}
+		wd, ok := c.(*remoteWebDriver)
+		if !ok {
+			return nil, nil, fmt.Errorf("unsupported WebDriver implementation %T", c)
+		}
+		return wd, nil, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported SearchContext implementation %T", ctx)
+}
+
+// findByScript finds elements in ctx with script, which gets the element
+// to search in, or null for the document, followed by args.
+func findByScript(ctx SearchContext, script string, args ...interface{}) ([]WebElement, error) {
+	wd, elem, err := searchDriver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	if elem != nil {
+		root = elem
+	}
+
+	res, err := wd.ExecuteScript(script, append([]interface{}{root}, args...))
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var refs []element
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, err
+	}
+	elems := make([]WebElement, len(refs))
+	for i, ref := range refs {
+		elems[i] = &remoteWE{parent: wd, id: ref.id()}
+	}
+	return elems, nil
+}
+
+const textMatchingScript = `
+var root = arguments[0] || document, re = new RegExp(arguments[1], arguments[2]);
+function matches(e) {
+  return e.tagName !== 'SCRIPT' && e.tagName !== 'STYLE' &&
+    re.test((e.textContent || '').replace(/\s+/g, ' ').trim());
+}
+var all = root.querySelectorAll('*'), found = [];
+for (var i = 0; i < all.length; i++) {
+  if (matches(all[i]) && !Array.prototype.some.call(all[i].children, matches)) {
+    found.push(all[i]);
+  }
+}
+return found;
+`
+
+const roleScript = `
+var root = arguments[0] || document, wantRole = arguments[1], wantName = arguments[2];
+function text(s) {
+  return (s || '').replace(/\s+/g, ' ').trim();
+}
+function implicitRole(e) {
+  var tag = e.tagName.toLowerCase(), type = (e.getAttribute('type') || '').toLowerCase();
+  switch (tag) {
+  case 'a': case 'area': return e.hasAttribute('href') ? 'link' : null;
+  case 'button': return 'button';
+  case 'input':
+    if (['button', 'submit', 'reset', 'image'].indexOf(type) >= 0) return 'button';
+    if (type === 'checkbox') return 'checkbox';
+    if (type === 'radio') return 'radio';
+    if (type === 'range') return 'slider';
+    if (type === 'number') return 'spinbutton';
+    if (type === 'search') return e.hasAttribute('list') ? 'combobox' : 'searchbox';
+    if (['', 'text', 'email', 'tel', 'url'].indexOf(type) >= 0) return e.hasAttribute('list') ? 'combobox' : 'textbox';
+    return null;
+  case 'textarea': return 'textbox';
+  case 'select': return e.multiple || e.size > 1 ? 'listbox' : 'combobox';
+  case 'option': return 'option';
+  case 'h1': case 'h2': case 'h3': case 'h4': case 'h5': case 'h6': return 'heading';
+  case 'img': return e.getAttribute('alt') === '' ? 'presentation' : 'img';
+  case 'ul': case 'ol': return 'list';
+  case 'li': return 'listitem';
+  case 'nav': return 'navigation';
+  case 'main': return 'main';
+  case 'header': return 'banner';
+  case 'footer': return 'contentinfo';
+  case 'aside': return 'complementary';
+  case 'form': return 'form';
+  case 'article': return 'article';
+  case 'dialog': return 'dialog';
+  case 'table': return 'table';
+  case 'tr': return 'row';
+  case 'td': return 'cell';
+  case 'th': return 'columnheader';
+  case 'progress': return 'progressbar';
+  }
+  return null;
+}
+function role(e) {
+  var r = text(e.getAttribute('role'));
+  return r ? r.split(' ')[0] : implicitRole(e);
+}
+var nameFromContent = ['button', 'link', 'heading', 'tab', 'menuitem', 'option', 'cell',
+  'columnheader', 'row', 'listitem', 'checkbox', 'radio', 'switch', 'treeitem'];
+function name(e) {
+  var ids = text(e.getAttribute('aria-labelledby'));
+  if (ids) {
+    return text(ids.split(' ').map(function(id) {
+      var l = document.getElementById(id);
+      return l ? l.textContent : '';
+    }).join(' '));
+  }
+  if (text(e.getAttribute('aria-label'))) return text(e.getAttribute('aria-label'));
+  if (e.labels && e.labels.length) {
+    return text(Array.prototype.map.call(e.labels, function(l) { return l.textContent; }).join(' '));
+  }
+  if (e.hasAttribute('alt')) return text(e.getAttribute('alt'));
+  if (e.tagName === 'INPUT' && ['button', 'submit', 'reset'].indexOf(e.type) >= 0) {
+    return text(e.value || {submit: 'Submit', reset: 'Reset'}[e.type]);
+  }
+  if (nameFromContent.indexOf(role(e)) >= 0 && text(e.textContent)) return text(e.textContent);
+  return text(e.getAttribute('title') || e.getAttribute('placeholder'));
+}
+function visible(e) {
+  return e.getClientRects().length > 0 && !e.closest('[aria-hidden="true"]');
+}
+var all = root.querySelectorAll('*'), found = [];
+for (var i = 0; i < all.length; i++) {
+  var e = all[i];
+  if (role(e) === wantRole && visible(e) && (wantName === null || name(e) === text(wantName))) {
+    found.push(e);
+  }
+}
+return found;
+`
@@ -0,0 +1,106 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestLocators(t *testing.T) {
+	for _, test := range []struct {
+		b                  By
+		using, value, desc string
+	}{
+		{ByTextContaining("Sign  in"), ByXPATH,
+			`//*[contains(normalize-space(.), "Sign in") and not(*[contains(normalize-space(.), "Sign in")])]`,
+			`text containing "Sign  in"`},
+		{ByLabel("Email"), ByXPATH,
+			`//*[@id=//label[normalize-space(.)="Email"]/@for] | //label[normalize-space(.)="Email"]//*[self::input or self::select or self::textarea] | //*[@aria-label="Email"]`,
+			`label "Email"`},
+		{ByPlaceholder(`Say "hi"`), ByCSSSelector, `[placeholder="Say \"hi\""]`, `placeholder "Say \"hi\""`},
+		{ByTestID("submit"), ByCSSSelector, `[data-testid="submit"]`, `test ID "submit"`},
+		{ByTestIDAttr("data-qa", "submit"), ByCSSSelector, `[data-qa="submit"]`, `data-qa "submit"`},
+		{ByRole("button", "Save"), "", "", `role "button" named "Save"`},
+		{ByTextMatching(regexp.MustCompile(`(?i)^total: \d+$`)), "", "", `text matching /^total: \d+$/i`},
+	} {
+		if test.b.Using != test.using || test.b.Value != test.value {
+			t.Errorf("%s: got %s %q, want %s %q", test.desc, test.b.Using, test.b.Value, test.using, test.value)
+		}
+		if got := test.b.String(); got != test.desc {
+			t.Errorf("String() = %s, want %s", got, test.desc)
+		}
+	}
+}
+
+func TestRelativeXPath(t *testing.T) {
+	for expr, want := range map[string]string{
+		`li`:                       `li`,
+		`//li`:                     `.//li`,
+		`//a | //b[@x="|"] | c`:    `.//a | .//b[@x="|"] | c`,
+		`//*[@id=//label/@for]|/a`: `.//*[@id=//label/@for]|./a`,
+		`(//li)[1]`:                `(.//li)[1]`,
+		`((//a | b)[2]/c) | //d`:   `((.//a | b)[2]/c) | .//d`,
+	} {
+		got, err := relativeXPath(expr)
+		if err != nil || got != want {
+			t.Errorf("relativeXPath(%q) = %q, %v, want %q", expr, got, err, want)
+		}
+	}
+	for _, expr := range []string{`id("main")//li`, `(//a) | ($items)[1]`} {
+		if got, err := relativeXPath(expr); err == nil {
+			t.Errorf("relativeXPath(%q) = %q, want an error", expr, got)
+		}
+	}
+}
+
+func TestBy_FindByScript(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "form"}]}`)
+	})
+	var args [][]interface{}
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Script string
+			Args   []interface{}
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		if v.Script != roleScript {
+			t.Error("unexpected script")
+		}
+		args = append(args, v.Args)
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "b1"}, {"ELEMENT": "b2"}]}`)
+	})
+
+	elems, err := ByRole("button", "").FindElements(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(elems) != 2 {
+		t.Fatalf("got %d elements, want 2", len(elems))
+	}
+	if id, _ := elementID(elems[1]); id != "b2" {
+		t.Errorf("got element %s, want b2", id)
+	}
+
+	e, err := ByCSS("form").Then(ByRole("button", "Save")).FindElement(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := elementID(e); id != "b1" {
+		t.Errorf("got element %s, want b1", id)
+	}
+
+	want := [][]interface{}{
+		{nil, "button", nil},
+		{map[string]interface{}{"ELEMENT": "form"}, "button", "Save"},
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("script args = %v, want %v", args, want)
+	}
+}
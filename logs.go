@@ -0,0 +1,83 @@
+package selenium
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+/* Log types */
+const (
+	BrowserLog     = "browser"
+	DriverLog      = "driver"
+	PerformanceLog = "performance"
+	ServerLog      = "server"
+	ClientLog      = "client"
+)
+
+/* Log levels */
+const (
+	LogAll     = "ALL"
+	LogDebug   = "DEBUG"
+	LogInfo    = "INFO"
+	LogWarning = "WARNING"
+	LogSevere  = "SEVERE"
+	LogOff     = "OFF"
+)
+
+// A LogEntry is a single message in a log returned by Log.
+type LogEntry struct {
+	Timestamp time.Time
+	Level     string
+	Message   string
+}
+
+func (e *LogEntry) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Timestamp float64 `json:"timestamp"`
+		Level     string  `json:"level"`
+		Message   string  `json:"message"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	// Timestamps are in milliseconds since the epoch, and may have a
+	// fractional part. Whole milliseconds are converted separately to
+	// keep them exact.
+	ms := math.Floor(v.Timestamp)
+	e.Timestamp = time.Unix(0, int64(ms)*int64(time.Millisecond)+int64(math.Round((v.Timestamp-ms)*float64(time.Millisecond))))
+	e.Level = v.Level
+	e.Message = v.Message
+	return nil
+}
+
+// SetLogLevel asks the driver to collect messages of the given log type
+// (e.g. BrowserLog) at level and above, so that they can be read with Log.
+// It sets both the legacy loggingPrefs capability and its Chrome-prefixed
+// W3C counterpart.
+func (c Capabilities) SetLogLevel(logType, level string) {
+	for _, key := range []string{"loggingPrefs", "goog:loggingPrefs"} {
+		prefs, _ := c[key].(map[string]interface{})
+		if prefs == nil {
+			prefs = make(map[string]interface{})
+			c[key] = prefs
+		}
+		prefs[logType] = level
+	}
+}
+
+func (wd *remoteWebDriver) LogTypes() ([]string, error) {
+	return wd.stringsCommand("/session/%s/log/types")
+}
+
+func (wd *remoteWebDriver) Log(logType string) (entries []LogEntry, err error) {
+	var data []byte
+	if data, err = json.Marshal(map[string]string{"type": logType}); err != nil {
+		return nil, err
+	}
+	var r *reply
+	if r, err = wd.send("POST", wd.url("/session/%s/log", wd.id), data); err == nil {
+		err = r.readValue(&entries)
+	}
+	return
+}
@@ -0,0 +1,83 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLogTypes(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/log/types", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"status": 0, "value": ["browser", "driver"]}`)
+	})
+
+	types, err := client.LogTypes()
+	if err != nil {
+		t.Fatalf("LogTypes returned error: %v", err)
+	}
+	if want := []string{BrowserLog, DriverLog}; !reflect.DeepEqual(types, want) {
+		t.Errorf("LogTypes returned %q, want %q", types, want)
+	}
+}
+
+func TestLog(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/log", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		if v["type"] != BrowserLog {
+			t.Errorf("Log type = %q, want %q", v["type"], BrowserLog)
+		}
+
+		fmt.Fprint(w, `{"status": 0, "value": [
+			{"timestamp": 1484300000123, "level": "SEVERE", "message": "Uncaught TypeError"},
+			{"timestamp": 1712345678901.5, "level": "INFO", "message": "a"},
+			{"timestamp": 1.7e12, "level": "INFO", "message": "b"}
+		]}`)
+	})
+
+	entries, err := client.Log(BrowserLog)
+	if err != nil {
+		t.Fatalf("Log returned error: %v", err)
+	}
+	want := []LogEntry{{
+		Timestamp: time.Unix(1484300000, 123*int64(time.Millisecond)),
+		Level:     LogSevere,
+		Message:   "Uncaught TypeError",
+	}, {
+		Timestamp: time.Unix(1712345678, 901500*int64(time.Microsecond)),
+		Level:     LogInfo,
+		Message:   "a",
+	}, {
+		Timestamp: time.Unix(1700000000, 0),
+		Level:     LogInfo,
+		Message:   "b",
+	}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("Log returned %+v, want %+v", entries, want)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	c := Capabilities{}
+	c.SetLogLevel(BrowserLog, LogAll)
+	c.SetLogLevel(DriverLog, LogWarning)
+
+	want := map[string]interface{}{BrowserLog: LogAll, DriverLog: LogWarning}
+	for _, key := range []string{"loggingPrefs", "goog:loggingPrefs"} {
+		if !reflect.DeepEqual(c[key], want) {
+			t.Errorf("%s = %+v, want %+v", key, c[key], want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package selenium
+
+import (
+	"strings"
+	"time"
+)
+
+// A Command is a request sent to the remote end.
+type Command struct {
+	// Name identifies the command by the path of its URL, with the IDs in
+	// it replaced by placeholders, e.g.
+	// "/session/:sessionId/element/:id/click".
+	Name   string
+	Method string
+	URL    string
+	// Params is the JSON body of the request, or nil.
+	Params []byte
+	// SessionID is the ID of the session the command is sent in, empty for
+	// commands such as NewSession and Status.
+	SessionID string
+}
+
+// A Handler sends a command and returns the body of the reply.
+type Handler func(cmd *Command) ([]byte, error)
+
+// A Middleware wraps the Handler sending commands, to observe or modify
+// them, answer them itself, or send them again.
+type Middleware func(next Handler) Handler
+
+// WithMiddleware wraps every command sent by the WebDriver, including the
+// one creating the session, with mw. The first middleware is the
+// outermost.
+func WithMiddleware(mw ...Middleware) RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.middleware = append(wd.middleware, mw...)
+	}
+}
+
+// Observe returns a Middleware that calls fn after each command with the
+// reply, error and duration of the command.
+func Observe(fn func(cmd *Command, reply []byte, err error, d time.Duration)) Middleware {
+	return func(next Handler) Handler {
+		return func(cmd *Command) ([]byte, error) {
+			start := time.Now()
+			reply, err := next(cmd)
+			fn(cmd, reply, err, time.Since(start))
+			return reply, err
+		}
+	}
+}
+
+// idParents maps the path segments followed by an ID to the segments that
+// may follow them instead.
+var idParents = map[string][]string{
+	"element":   {"active"},
+	"shadow":    nil,
+	"window":    {"handles", "rect", "maximize", "minimize", "fullscreen", "new", "size", "position"},
+	"frame":     {"parent"},
+	"cookie":    nil,
+	"key":       nil,
+	"files":     nil,
+	"attribute": nil,
+	"property":  nil,
+	"css":       nil,
+}
+
+// commandName returns the Name of the Command with the given URL.
+func (wd *remoteWebDriver) commandName(url string) string {
+	path := strings.TrimPrefix(url, wd.executor)
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		prev, seg := segments[i-1], segments[i]
+		if prev == "session" && seg != "" && i == 2 {
+			segments[i] = ":sessionId"
+			continue
+		}
+		exempt, ok := idParents[prev]
+		if !ok || seg == "" {
+			continue
+		}
+		isID := true
+		for _, e := range exempt {
+			if seg == e {
+				isID = false
+			}
+		}
+		if isID {
+			segments[i] = placeholder(prev)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// placeholder returns the placeholder of the ID following parent.
+func placeholder(parent string) string {
+	switch parent {
+	case "element", "shadow", "frame":
+		return ":id"
+	case "window":
+		return ":windowHandle"
+	case "key":
+		return ":key"
+	}
+	return ":name"
+}
@@ -0,0 +1,138 @@
+package selenium
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCommandName(t *testing.T) {
+	wd := &remoteWebDriver{executor: "http://hub/wd/hub"}
+	for url, want := range map[string]string{
+		"http://hub/wd/hub/session":                               "/session",
+		"http://hub/wd/hub/status":                                "/status",
+		"http://hub/wd/hub/session/123/element":                   "/session/:sessionId/element",
+		"http://hub/wd/hub/session/123/element/active":            "/session/:sessionId/element/active",
+		"http://hub/wd/hub/session/123/element/e1/click":          "/session/:sessionId/element/:id/click",
+		"http://hub/wd/hub/session/123/element/e1/element":        "/session/:sessionId/element/:id/element",
+		"http://hub/wd/hub/session/123/element/e1/attribute/href": "/session/:sessionId/element/:id/attribute/:name",
+		"http://hub/wd/hub/session/123/window/handles":            "/session/:sessionId/window/handles",
+		"http://hub/wd/hub/session/123/window/current/size":       "/session/:sessionId/window/:windowHandle/size",
+		"http://hub/wd/hub/session/123/cookie/sid":                "/session/:sessionId/cookie/:name",
+		"http://hub/wd/hub/session/123/local_storage/key/k?x=1":   "/session/:sessionId/local_storage/key/:key",
+		"http://hub/wd/hub/session/123/timeouts/implicit_wait":    "/session/:sessionId/timeouts/implicit_wait",
+	} {
+		if got := wd.commandName(url); got != want {
+			t.Errorf("commandName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestWithMiddleware(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "http://example.com/"}`)
+	})
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "real"}`)
+	})
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(cmd *Command) ([]byte, error) {
+				order = append(order, name+" "+cmd.Method+" "+cmd.Name)
+				return next(cmd)
+			}
+		}
+	}
+	// Answers Title itself.
+	stub := func(next Handler) Handler {
+		return func(cmd *Command) ([]byte, error) {
+			if cmd.Name == "/session/:sessionId/title" {
+				return []byte(`{"status": 0, "value": "stubbed"}`), nil
+			}
+			return next(cmd)
+		}
+	}
+	var observed []string
+	observe := Observe(func(cmd *Command, reply []byte, err error, d time.Duration) {
+		observed = append(observed, fmt.Sprintf("%s %s %v", cmd.SessionID, reply, err))
+	})
+
+	wd, err := NewRemote(caps, server.URL, WithMiddleware(trace("a"), trace("b"), observe, stub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url, err := wd.CurrentURL(); err != nil || url != "http://example.com/" {
+		t.Errorf("CurrentURL returned %q, %v", url, err)
+	}
+	if title, err := wd.Title(); err != nil || title != "stubbed" {
+		t.Errorf("Title returned %q, %v, want stubbed", title, err)
+	}
+
+	wantOrder := []string{
+		"a POST /session", "b POST /session",
+		"a GET /session/:sessionId/url", "b GET /session/:sessionId/url",
+		"a GET /session/:sessionId/title", "b GET /session/:sessionId/title",
+	}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Errorf("middleware saw %q, want %q", order, wantOrder)
+	}
+	wantObserved := []string{
+		` {"sessionId": "123"} <nil>`,
+		`123 {"status": 0, "value": "http://example.com/"} <nil>`,
+		`123 {"status": 0, "value": "stubbed"} <nil>`,
+	}
+	if !reflect.DeepEqual(observed, wantObserved) {
+		t.Errorf("Observe saw %q, want %q", observed, wantObserved)
+	}
+}
+
+func TestWithMiddleware_Retry(t *testing.T) {
+	setup()
+	defer teardown()
+
+	calls := 0
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": "ok"}`)
+	})
+	retry := func(next Handler) Handler {
+		return func(cmd *Command) ([]byte, error) {
+			reply, err := next(cmd)
+			if err != nil {
+				return next(cmd)
+			}
+			return reply, err
+		}
+	}
+	errorOnQuit := func(next Handler) Handler {
+		return func(cmd *Command) ([]byte, error) {
+			if cmd.Method == "DELETE" && cmd.Name == "/session/:sessionId" {
+				return nil, errors.New("refused")
+			}
+			return next(cmd)
+		}
+	}
+
+	wd, err := NewRemote(caps, server.URL, WithMiddleware(retry, errorOnQuit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title, err := wd.Title(); err != nil || title != "ok" || calls != 2 {
+		t.Errorf("Title returned %q, %v after %d calls, want ok after 2", title, err, calls)
+	}
+	if err := wd.Quit(); err == nil || err.Error() != "refused" {
+		t.Errorf("Quit returned %v, want the middleware's error", err)
+	}
+}
@@ -0,0 +1,246 @@
+package netcapture
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HAR is an HTTP Archive, see http://www.softwareishard.com/blog/har-12-spec/.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// Log is the root of a HAR document.
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+// Creator names the application that created a HAR document.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// An Entry is a request and its response.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the total time of the request in milliseconds.
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	Cache    struct{} `json:"cache"`
+	Timings  Timings  `json:"timings"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+// A Request is the request of an Entry.
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// A Response is the response of an Entry.
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// NameValue is a header or query string parameter.
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// A Cookie is a cookie sent with a request or set by a response.
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+// PostData is the body of a request.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Content is the body of a response, decoded if it was compressed with
+// gzip or deflate. Binary bodies are base64 encoded.
+type Content struct {
+	Size int `json:"size"`
+	// Compression is the number of bytes saved by compression.
+	Compression int    `json:"compression,omitempty"`
+	MimeType    string `json:"mimeType"`
+	Text        string `json:"text,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+}
+
+// Timings break down the time of an Entry, in milliseconds. Phases that
+// the proxy can't measure are -1.
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func headers(h http.Header) []NameValue {
+	nvs := []NameValue{}
+	for name, values := range h {
+		for _, value := range values {
+			nvs = append(nvs, NameValue{Name: name, Value: value})
+		}
+	}
+	return nvs
+}
+
+func queryString(u *url.URL) []NameValue {
+	nvs := []NameValue{}
+	for name, values := range u.Query() {
+		for _, value := range values {
+			nvs = append(nvs, NameValue{Name: name, Value: value})
+		}
+	}
+	return nvs
+}
+
+func cookies(cs []*http.Cookie) []Cookie {
+	hcs := []Cookie{}
+	for _, c := range cs {
+		hc := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			hc.Expires = &expires
+		}
+		hcs = append(hcs, hc)
+	}
+	return hcs
+}
+
+// isText reports whether a body of the given MIME type can be stored as
+// text rather than base64.
+func isText(mimeType string) bool {
+	t, _, _ := mime.ParseMediaType(mimeType)
+	return strings.HasPrefix(t, "text/") ||
+		strings.HasSuffix(t, "json") ||
+		strings.HasSuffix(t, "xml") ||
+		strings.HasSuffix(t, "javascript") ||
+		t == "application/x-www-form-urlencoded"
+}
+
+func newRequest(r *http.Request, body []byte) Request {
+	req := Request{
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		HTTPVersion: r.Proto,
+		Cookies:     cookies(r.Cookies()),
+		Headers:     headers(r.Header),
+		QueryString: queryString(r.URL),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if len(body) > 0 {
+		req.PostData = &PostData{MimeType: r.Header.Get("Content-Type"), Text: string(body)}
+	}
+	return req
+}
+
+// noResponse is the Response of a request that got none.
+func noResponse() Response {
+	return Response{Cookies: []Cookie{}, Headers: []NameValue{}, HeadersSize: -1, BodySize: -1}
+}
+
+// decode returns body decoded as the Content-Encoding encoding says, if it
+// is gzip or deflate.
+func decode(encoding string, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "gzip", "x-gzip":
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	case "deflate":
+		// Deflate should be wrapped in zlib, but some servers send it raw.
+		if r, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			if decoded, err := ioutil.ReadAll(r); err == nil {
+				return decoded, nil
+			}
+		}
+		return ioutil.ReadAll(flate.NewReader(bytes.NewReader(body)))
+	}
+	return body, nil
+}
+
+func newResponse(res *http.Response, body []byte) Response {
+	mimeType := res.Header.Get("Content-Type")
+	text := isText(mimeType)
+	decoded, err := decode(res.Header.Get("Content-Encoding"), body)
+	if err != nil {
+		// Keep the body as sent, which isn't text.
+		decoded, text = body, false
+	}
+	content := Content{Size: len(decoded), Compression: len(decoded) - len(body), MimeType: mimeType}
+	if text {
+		content.Text = string(decoded)
+	} else if len(decoded) > 0 {
+		content.Text = base64.StdEncoding.EncodeToString(decoded)
+		content.Encoding = "base64"
+	}
+	return Response{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: res.Proto,
+		Cookies:     cookies(res.Cookies()),
+		Headers:     headers(res.Header),
+		Content:     content,
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
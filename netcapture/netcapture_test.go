@@ -0,0 +1,333 @@
+package netcapture
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"sourcegraph.com/sourcegraph/go-selenium"
+)
+
+func startProxy(t *testing.T, transport http.RoundTripper) (*Proxy, *http.Client) {
+	p := &Proxy{Transport: transport}
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(p.CACertificate())
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(p.URL()),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+	return p, client
+}
+
+func TestProxy_HTTP(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"method": %q, "body": %q}`, r.Method, body)
+	}))
+	defer upstream.Close()
+
+	p, client := startProxy(t, nil)
+	defer p.Close()
+
+	res, err := client.Post(upstream.URL+"/api/items?page=2", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if want := `{"method": "POST", "body": "hello"}`; string(body) != want {
+		t.Errorf("got body %q, want %q", body, want)
+	}
+
+	entries := p.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if want := upstream.URL + "/api/items?page=2"; e.Request.Method != "POST" || e.Request.URL != want {
+		t.Errorf("got request %s %s, want POST %s", e.Request.Method, e.Request.URL, want)
+	}
+	if e.Request.PostData == nil || e.Request.PostData.Text != "hello" {
+		t.Errorf("got post data %+v, want hello", e.Request.PostData)
+	}
+	if want := []NameValue{{"page", "2"}}; len(e.Request.QueryString) != 1 || e.Request.QueryString[0] != want[0] {
+		t.Errorf("got query string %v, want %v", e.Request.QueryString, want)
+	}
+	if e.Response.Status != 200 || e.Response.Content.Text != string(body) || e.Response.Content.Encoding != "" {
+		t.Errorf("got response %+v", e.Response)
+	}
+	if len(e.Response.Cookies) != 1 || e.Response.Cookies[0].Name != "session" {
+		t.Errorf("got response cookies %+v, want session", e.Response.Cookies)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteHAR(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var har HAR
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("HAR is not valid JSON: %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Errorf("got HAR version %q with %d entries", har.Log.Version, len(har.Log.Entries))
+	}
+
+	p.Reset()
+	if n := len(p.Entries()); n != 0 {
+		t.Errorf("got %d entries after Reset, want 0", n)
+	}
+}
+
+func TestProxy_Compressed(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		fmt.Fprint(gz, `{"ok":true}`)
+		gz.Close()
+	}))
+	defer upstream.Close()
+
+	p, client := startProxy(t, nil)
+	defer p.Close()
+
+	res, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != `{"ok":true}` {
+		t.Errorf("got body %q", body)
+	}
+
+	entries := p.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	r := entries[0].Response
+	if r.Content.Text != `{"ok":true}` || r.Content.Size != len(body) || r.Content.Encoding != "" {
+		t.Errorf("got content %+v, want the decoded body", r.Content)
+	}
+	if r.BodySize == len(body) || r.Content.Compression != len(body)-r.BodySize {
+		t.Errorf("got body size %d and compression %d, want the compressed size", r.BodySize, r.Content.Compression)
+	}
+}
+
+func TestProxy_HTTPS(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte{0x89, 'P', 'N', 'G'})
+	}))
+	defer upstream.Close()
+
+	p, client := startProxy(t, upstream.Client().Transport)
+	defer p.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := client.Get(upstream.URL + "/logo.png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+	}
+
+	entries := p.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[1]
+	if want := upstream.URL + "/logo.png"; e.Request.URL != want {
+		t.Errorf("got request URL %q, want %q", e.Request.URL, want)
+	}
+	if c := e.Response.Content; c.Encoding != "base64" || c.Text != "iVBORw==" || c.Size != 4 {
+		t.Errorf("got content %+v, want base64 PNG", c)
+	}
+}
+
+func TestProxy_CloseTunnels(t *testing.T) {
+	p, _ := startProxy(t, nil)
+	conn, err := net.Dial("tcp", p.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n")
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("CONNECT returned %v, %v", res, err)
+	}
+
+	p.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("read from tunnel after Close returned %v, want EOF", err)
+	}
+}
+
+func TestProxy_UpstreamError(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	url := upstream.URL
+	upstream.Close()
+
+	p, client := startProxy(t, nil)
+	defer p.Close()
+
+	res, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadGateway {
+		t.Errorf("got status %d, want %d", res.StatusCode, http.StatusBadGateway)
+	}
+	if entries := p.Entries(); len(entries) != 1 || entries[0].Comment == "" {
+		t.Errorf("got entries %+v, want one with an error comment", entries)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	p, _ := startProxy(t, nil)
+	defer p.Close()
+
+	caps := selenium.Capabilities{"browserName": "firefox"}
+	if err := p.Configure(caps); err != nil {
+		t.Fatal(err)
+	}
+	proxy, _ := caps["proxy"].(map[string]interface{})
+	if proxy["proxyType"] != "manual" || proxy["httpProxy"] != p.Addr() || proxy["sslProxy"] != p.Addr() {
+		t.Errorf("got proxy capability %v", caps["proxy"])
+	}
+	if caps["acceptInsecureCerts"] != true {
+		t.Error("acceptInsecureCerts not set")
+	}
+	if err := p.Configure(selenium.Capabilities{}); err != ErrInUse {
+		t.Errorf("second Configure returned %v, want ErrInUse", err)
+	}
+}
+
+func TestProxy_DelayCanceled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+
+	p, client := startProxy(t, nil)
+	defer p.Close()
+	p.AddRule(&Rule{Delay: time.Hour})
+
+	client.Timeout = 50 * time.Millisecond
+	if _, err := client.Get(upstream.URL); err == nil {
+		t.Fatal("delayed request returned no error")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(p.Entries()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("delay not canceled with the request")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if e := p.Entries()[0]; e.Comment != "context canceled" {
+		t.Errorf("got entry comment %q, want context canceled", e.Comment)
+	}
+}
+
+func TestProxy_Rules(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "1")
+		fmt.Fprintf(w, "upstream %s", r.Header.Get("Authorization"))
+	}))
+	defer upstream.Close()
+
+	p, client := startProxy(t, nil)
+	defer p.Close()
+
+	get := func(path string) (*http.Response, string, error) {
+		res, err := client.Get(upstream.URL + path)
+		if err != nil {
+			return nil, "", err
+		}
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		return res, string(body), err
+	}
+
+	mock := &Rule{
+		Method:  "GET",
+		URL:     regexp.MustCompile(`/api/`),
+		Respond: &MockResponse{Status: 500, Body: []byte(`{"error": "down"}`), Header: http.Header{"Content-Type": {"application/json"}}},
+	}
+	p.AddRule(mock)
+	p.AddRule(&Rule{
+		URL:            regexp.MustCompile(`/slow$`),
+		Delay:          50 * time.Millisecond,
+		RequestHeader:  http.Header{"Authorization": {"Bearer t"}},
+		ResponseHeader: http.Header{"X-Upstream": nil, "X-Rule": {"slow"}},
+	})
+	p.AddRule(&Rule{URL: regexp.MustCompile(`/broken$`), Fail: true})
+
+	res, body, err := get("/api/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 500 || body != `{"error": "down"}` {
+		t.Errorf("got mocked response %d %q", res.StatusCode, body)
+	}
+
+	start := time.Now()
+	res, body, err = get("/slow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("delayed request took %v, want at least 50ms", d)
+	}
+	if body != "upstream Bearer t" {
+		t.Errorf("got body %q, want request header set by rule", body)
+	}
+	if res.Header.Get("X-Upstream") != "" || res.Header.Get("X-Rule") != "slow" {
+		t.Errorf("got response headers %v, want X-Upstream removed and X-Rule set", res.Header)
+	}
+
+	if _, _, err := get("/broken"); err == nil {
+		t.Error("failed request returned no error")
+	}
+
+	p.RemoveRule(mock)
+	if _, body, err := get("/api/items"); err != nil || body != "upstream " {
+		t.Errorf("after RemoveRule got %q, %v, want upstream response", body, err)
+	}
+	p.ClearRules()
+	if _, _, err := get("/broken"); err != nil {
+		t.Errorf("after ClearRules got error %v", err)
+	}
+
+	// The client may retry the failed request, so find entries by comment.
+	comments := map[string]int{}
+	for _, e := range p.Entries() {
+		comments[e.Comment]++
+		if e.Comment == "netcapture: failed by rule" && e.Response.Status != 0 {
+			t.Errorf("got failed entry with status %d", e.Response.Status)
+		}
+	}
+	if comments["netcapture: mocked by rule"] != 1 || comments["netcapture: failed by rule"] == 0 {
+		t.Errorf("got entry comments %v, want one mocked and a failed entry", comments)
+	}
+}
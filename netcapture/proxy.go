@@ -0,0 +1,501 @@
+// Package netcapture records the HTTP traffic of a browser through a local
+// proxy, so that tests can assert on the requests a page made without
+// relying on browser-specific logging.
+//
+// HTTPS traffic is intercepted with certificates signed by a CA generated
+// when the proxy starts; Configure makes the session accept them.
+//
+//	p, err := netcapture.Start()
+//	...
+//	defer p.Close()
+//	wd, err := p.NewRemote(caps, executor)
+//	...
+//	err = p.WriteHAR(f)
+//
+// A Proxy serves a single session, so that its rules and recorded traffic
+// are that session's; start one proxy per session.
+//
+// Browsers don't send requests for localhost through a proxy, so pages
+// under test should be served from another host name.
+package netcapture
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"sourcegraph.com/sourcegraph/go-selenium"
+)
+
+// hopHeaders are the headers that apply to a single connection, and are
+// not forwarded.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// ErrInUse is returned when configuring a second session to use a Proxy.
+var ErrInUse = errors.New("netcapture: proxy already configured for a session; start one proxy per session")
+
+// A Proxy is an HTTP(S) proxy that records the requests sent through it.
+// The zero value is ready to Start.
+type Proxy struct {
+	// Transport sends the proxied requests. If nil, http.DefaultTransport
+	// is used. It must be set before the proxy is started.
+	Transport http.RoundTripper
+
+	listener net.Listener
+	server   *http.Server
+	ca       *tls.Certificate
+
+	certMu sync.Mutex
+	certs  map[string]*tls.Certificate
+
+	// tunnels are the intercepted CONNECT tunnels, closed by Close.
+	tunnelMu sync.Mutex
+	tunnels  map[*tunnel]bool
+	closed   bool
+
+	mu      sync.Mutex
+	entries []Entry
+	rules   []*Rule
+	// configured is whether a session was configured to use the proxy.
+	configured bool
+}
+
+// Start starts a new proxy on a random local port.
+func Start() (*Proxy, error) {
+	p := &Proxy{}
+	if err := p.Start(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Start starts the proxy on a random local port.
+func (p *Proxy) Start() error {
+	ca, err := newCA()
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	p.ca = ca
+	p.certs = make(map[string]*tls.Certificate)
+	p.tunnels = make(map[*tunnel]bool)
+	p.closed = false
+	p.listener = l
+	p.server = &http.Server{Handler: p}
+	go p.server.Serve(l)
+	return nil
+}
+
+// Close stops the proxy and closes the connections through it. The
+// recorded entries remain available.
+func (p *Proxy) Close() error {
+	err := p.server.Close()
+	p.tunnelMu.Lock()
+	p.closed = true
+	tunnels := p.tunnels
+	p.tunnels = nil
+	p.tunnelMu.Unlock()
+	for t := range tunnels {
+		t.close()
+	}
+	return err
+}
+
+// A tunnel is an intercepted CONNECT tunnel, and the server handling the
+// requests sent over it.
+type tunnel struct {
+	conn   net.Conn
+	server *http.Server
+}
+
+func (t *tunnel) close() {
+	t.server.Close()
+	t.conn.Close()
+}
+
+// Addr returns the host:port address the proxy listens on.
+func (p *Proxy) Addr() string {
+	return p.listener.Addr().String()
+}
+
+// URL returns the URL of the proxy, for use with http.ProxyURL.
+func (p *Proxy) URL() *url.URL {
+	return &url.URL{Scheme: "http", Host: p.Addr()}
+}
+
+// CACertificate returns the certificate of the CA that signs the
+// certificates of intercepted HTTPS hosts.
+func (p *Proxy) CACertificate() *x509.Certificate {
+	return p.ca.Leaf
+}
+
+// Configure sets the proxy capability of caps to send all HTTP and HTTPS
+// traffic through the proxy, and makes the session accept the proxy's
+// certificates. It returns ErrInUse if it was already called, since the
+// proxy can't tell the traffic of two sessions apart.
+func (p *Proxy) Configure(caps selenium.Capabilities) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.configured {
+		return ErrInUse
+	}
+	p.configured = true
+	caps["proxy"] = map[string]interface{}{
+		"proxyType": "manual",
+		"httpProxy": p.Addr(),
+		"sslProxy":  p.Addr(),
+	}
+	caps["acceptInsecureCerts"] = true
+	caps["acceptSslCerts"] = true
+	return nil
+}
+
+// NewRemote is like selenium.NewRemote, but configures the session to use
+// the proxy. caps is not modified.
+func (p *Proxy) NewRemote(caps selenium.Capabilities, executor string, opts ...selenium.RemoteOption) (selenium.WebDriver, error) {
+	c := make(selenium.Capabilities, len(caps)+3)
+	for k, v := range caps {
+		c[k] = v
+	}
+	if err := p.Configure(c); err != nil {
+		return nil, err
+	}
+	wd, err := selenium.NewRemote(c, executor, opts...)
+	if err != nil {
+		// No session uses the proxy after all.
+		p.mu.Lock()
+		p.configured = false
+		p.mu.Unlock()
+		return nil, err
+	}
+	return wd, nil
+}
+
+// Entries returns the requests recorded so far, in the order they
+// completed.
+func (p *Proxy) Entries() []Entry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Entry(nil), p.entries...)
+}
+
+// Reset discards the recorded requests.
+func (p *Proxy) Reset() {
+	p.mu.Lock()
+	p.entries = nil
+	p.mu.Unlock()
+}
+
+// HAR returns the recorded requests as an HTTP Archive.
+func (p *Proxy) HAR() *HAR {
+	return &HAR{Log: Log{
+		Version: "1.2",
+		Creator: Creator{Name: "go-selenium netcapture", Version: "1.0"},
+		Entries: p.Entries(),
+	}}
+}
+
+// WriteHAR writes the recorded requests to w as an HTTP Archive.
+func (p *Proxy) WriteHAR(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p.HAR())
+}
+
+func (p *Proxy) record(e Entry) {
+	p.mu.Lock()
+	p.entries = append(p.entries, e)
+	p.mu.Unlock()
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "CONNECT":
+		p.intercept(w, r)
+	case r.URL.IsAbs():
+		p.forward(w, r)
+	default:
+		http.Error(w, "netcapture: not a proxy request", http.StatusBadRequest)
+	}
+}
+
+// forward sends r upstream, or handles it as its rule says, writes the
+// response to w and records both.
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule := p.rule(r)
+	if rule != nil && rule.Delay > 0 {
+		t := time.NewTimer(rule.Delay)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			p.record(Entry{
+				StartedDateTime: start,
+				Time:            milliseconds(time.Since(start)),
+				Request:         newRequest(r, body),
+				Response:        noResponse(),
+				Timings:         Timings{Blocked: -1, DNS: -1, Connect: -1},
+				Comment:         r.Context().Err().Error(),
+			})
+			return
+		}
+	}
+	if rule != nil && rule.Fail {
+		p.record(Entry{
+			StartedDateTime: start,
+			Time:            milliseconds(time.Since(start)),
+			Request:         newRequest(r, body),
+			Response:        noResponse(),
+			Timings:         Timings{Blocked: -1, DNS: -1, Connect: -1},
+			Comment:         "netcapture: failed by rule",
+		})
+		// Closes the connection without a response.
+		panic(http.ErrAbortHandler)
+	}
+
+	var res *http.Response
+	var comment string
+	if rule != nil && rule.Respond != nil {
+		res = rule.Respond.response(r)
+		comment = "netcapture: mocked by rule"
+	} else {
+		out := new(http.Request)
+		*out = *r
+		out.RequestURI = ""
+		out.Header = cloneHeader(r.Header)
+		removeHopHeaders(out.Header)
+		if rule != nil {
+			applyHeader(out.Header, rule.RequestHeader)
+		}
+		out.Body = ioutil.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+		if len(body) == 0 {
+			out.Body = nil
+		}
+
+		transport := p.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		res, err = transport.RoundTrip(out)
+		if err != nil {
+			sent := time.Now()
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			p.record(Entry{
+				StartedDateTime: start,
+				Time:            milliseconds(sent.Sub(start)),
+				Request:         newRequest(r, body),
+				Response:        noResponse(),
+				Timings:         Timings{Blocked: -1, DNS: -1, Connect: -1, Wait: milliseconds(sent.Sub(start))},
+				Comment:         err.Error(),
+			})
+			return
+		}
+	}
+	sent := time.Now()
+	defer res.Body.Close()
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		comment = err.Error()
+	}
+	done := time.Now()
+
+	removeHopHeaders(res.Header)
+	if rule != nil {
+		applyHeader(res.Header, rule.ResponseHeader)
+	}
+	for k, vs := range res.Header {
+		w.Header()[k] = vs
+	}
+	w.WriteHeader(res.StatusCode)
+	w.Write(resBody)
+
+	p.record(Entry{
+		StartedDateTime: start,
+		Time:            milliseconds(done.Sub(start)),
+		Request:         newRequest(r, body),
+		Response:        newResponse(res, resBody),
+		Timings: Timings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			Wait:    milliseconds(sent.Sub(start)),
+			Receive: milliseconds(done.Sub(sent)),
+		},
+		Comment: comment,
+	})
+}
+
+// intercept answers a CONNECT request and serves the requests sent over
+// the tunnel itself, decrypting them with a certificate for the host.
+func (p *Proxy) intercept(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "netcapture: can't hijack connection", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	rw.WriteString("HTTP/1.1 200 Connection Established\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+
+	tlsConn := tls.Server(&bufferedConn{conn, rw.Reader}, &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			name := hello.ServerName
+			if name == "" {
+				name = host
+			}
+			return p.cert(name)
+		},
+	})
+	authority := r.Host
+	t := &tunnel{conn: conn, server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Scheme = "https"
+		r.URL.Host = r.Host
+		if r.URL.Host == "" {
+			r.URL.Host = authority
+		}
+		p.forward(w, r)
+	})}}
+	p.tunnelMu.Lock()
+	if p.closed {
+		p.tunnelMu.Unlock()
+		conn.Close()
+		return
+	}
+	p.tunnels[t] = true
+	p.tunnelMu.Unlock()
+
+	t.server.Serve(newConnListener(tlsConn))
+	p.tunnelMu.Lock()
+	delete(p.tunnels, t)
+	p.tunnelMu.Unlock()
+}
+
+// cert returns the certificate for host, making it on first use.
+func (p *Proxy) cert(host string) (*tls.Certificate, error) {
+	p.certMu.Lock()
+	defer p.certMu.Unlock()
+	if c, ok := p.certs[host]; ok {
+		return c, nil
+	}
+	c, err := newLeaf(p.ca, host)
+	if err != nil {
+		return nil, err
+	}
+	p.certs[host] = c
+	return c, nil
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, vs := range h {
+		c[k] = append([]string(nil), vs...)
+	}
+	return c
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, k := range hopHeaders {
+		h.Del(k)
+	}
+}
+
+// bufferedConn is a connection whose first bytes were already read into a
+// bufio.Reader.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// connListener is a net.Listener that accepts a single connection, and
+// blocks further calls to Accept until that connection is closed.
+type connListener struct {
+	conn   net.Conn
+	once   sync.Once
+	closed chan struct{}
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	l := &connListener{closed: make(chan struct{})}
+	l.conn = &closeNotifyConn{Conn: conn, l: l}
+	return l
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	if c := l.conn; c != nil {
+		l.conn = nil
+		return c, nil
+	}
+	<-l.closed
+	return nil, io.EOF
+}
+
+func (l *connListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return dummyAddr{}
+}
+
+type dummyAddr struct{}
+
+func (dummyAddr) Network() string { return "tcp" }
+func (dummyAddr) String() string  { return "netcapture" }
+
+// closeNotifyConn closes its listener when it is closed.
+type closeNotifyConn struct {
+	net.Conn
+	l *connListener
+}
+
+func (c *closeNotifyConn) Close() error {
+	err := c.Conn.Close()
+	c.l.Close()
+	return err
+}
@@ -0,0 +1,121 @@
+package netcapture
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// A Rule changes how the proxy handles the requests it matches: it may
+// delay them, modify their headers, answer them with a canned response,
+// or fail them with a network error.
+type Rule struct {
+	// Method matches the request method. Empty matches any method.
+	Method string
+	// URL matches the request URL. Nil matches any URL.
+	URL *regexp.Regexp
+
+	// Delay is how long to wait before handling the request, unless the
+	// request is canceled meanwhile.
+	Delay time.Duration
+	// RequestHeader is set on the request before it is sent upstream,
+	// and ResponseHeader on the response. Headers with no values are
+	// removed.
+	RequestHeader  http.Header
+	ResponseHeader http.Header
+	// Respond, if not nil, answers the request without sending it
+	// upstream.
+	Respond *MockResponse
+	// Fail closes the connection without answering the request, as if the
+	// network failed.
+	Fail bool
+}
+
+// A MockResponse is a canned response returned by a Rule.
+type MockResponse struct {
+	// Status is the status code; 0 means http.StatusOK.
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+func (r *Rule) matches(req *http.Request) bool {
+	if r.Method != "" && r.Method != req.Method {
+		return false
+	}
+	return r.URL == nil || r.URL.MatchString(req.URL.String())
+}
+
+// AddRule adds a rule for the session using the proxy. Requests are
+// handled by the first rule, in the order they were added, that matches
+// them.
+func (p *Proxy) AddRule(r *Rule) {
+	p.mu.Lock()
+	p.rules = append(p.rules, r)
+	p.mu.Unlock()
+}
+
+// RemoveRule removes a rule added by AddRule.
+func (p *Proxy) RemoveRule(r *Rule) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.rules {
+		if p.rules[i] == r {
+			p.rules = append(p.rules[:i:i], p.rules[i+1:]...)
+			return
+		}
+	}
+}
+
+// ClearRules removes all rules.
+func (p *Proxy) ClearRules() {
+	p.mu.Lock()
+	p.rules = nil
+	p.mu.Unlock()
+}
+
+// rule returns the first rule matching req, or nil.
+func (p *Proxy) rule(req *http.Request) *Rule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, r := range p.rules {
+		if r.matches(req) {
+			return r
+		}
+	}
+	return nil
+}
+
+func applyHeader(dst, src http.Header) {
+	for k, vs := range src {
+		if len(vs) == 0 {
+			dst.Del(k)
+			continue
+		}
+		dst[http.CanonicalHeaderKey(k)] = vs
+	}
+}
+
+func (m *MockResponse) response(req *http.Request) *http.Response {
+	status := m.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := cloneHeader(m.Header)
+	if header.Get("Content-Type") == "" && len(m.Body) > 0 {
+		header.Set("Content-Type", http.DetectContentType(m.Body))
+	}
+	return &http.Response{
+		Status:        http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(m.Body)),
+		ContentLength: int64(len(m.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,217 @@
+package selenium
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Pool.Get once the pool is closed.
+var ErrPoolClosed = errors.New("pool closed")
+
+// A Pool keeps a fixed number of sessions open and lends them to tests, so
+// that parallel tests don't pay the browser startup cost each. Since no more
+// than the pool's size sessions are open at once, the size also caps the
+// load put on a grid.
+//
+//	pool, err := selenium.NewPool(caps, executor, 4)
+//	...
+//	wd, err := pool.Get()
+//	...
+//	defer pool.Put(wd)
+type Pool struct {
+	caps     Capabilities
+	executor string
+	opts     []RemoteOption
+
+	// idle holds the sessions not lent, and a nil for each session that
+	// must be created again.
+	idle chan WebDriver
+	done chan struct{}
+
+	mu sync.Mutex
+	// leased are the sessions lent by Get and not yet returned.
+	leased map[WebDriver]bool
+	closed bool
+}
+
+// NewPool opens size sessions with the given capabilities, executor and
+// options, see NewRemote.
+func NewPool(capabilities Capabilities, executor string, size int, opts ...RemoteOption) (*Pool, error) {
+	if size < 1 {
+		return nil, errors.New("pool size must be at least 1")
+	}
+	p := &Pool{
+		caps:     capabilities,
+		executor: executor,
+		opts:     opts,
+		idle:     make(chan WebDriver, size),
+		done:     make(chan struct{}),
+		leased:   make(map[WebDriver]bool),
+	}
+
+	type result struct {
+		wd  WebDriver
+		err error
+	}
+	results := make(chan result, size)
+	for i := 0; i < size; i++ {
+		go func() {
+			wd, err := NewRemote(p.caps, p.executor, p.opts...)
+			results <- result{wd, err}
+		}()
+	}
+	var err error
+	for i := 0; i < size; i++ {
+		r := <-results
+		if r.err != nil {
+			err = r.err
+		}
+		p.idle <- r.wd
+	}
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// Get lends a session, waiting until one is returned if all are in use.
+// Sessions that fail a health check are replaced by new ones.
+func (p *Pool) Get() (WebDriver, error) {
+	var wd WebDriver
+	select {
+	case wd = <-p.idle:
+	case <-p.done:
+		return nil, ErrPoolClosed
+	}
+
+	if wd != nil {
+		if _, err := wd.CurrentWindowHandle(); err == nil {
+			return p.lend(wd), nil
+		}
+		if Log != nil {
+			Log.Printf("pool: replacing unhealthy session %s", wd.SessionID())
+		}
+		wd.Quit()
+	}
+	wd, err := NewRemote(p.caps, p.executor, p.opts...)
+	if err != nil {
+		p.release(nil)
+		return nil, err
+	}
+	return p.lend(wd), nil
+}
+
+// lend records wd as lent.
+func (p *Pool) lend(wd WebDriver) WebDriver {
+	p.mu.Lock()
+	p.leased[wd] = true
+	p.mu.Unlock()
+	return wd
+}
+
+// Put returns a session lent by Get to the pool, after deleting its
+// cookies and web storage, closing all its windows but one and navigating
+// to about:blank. Sessions that can't be reset are replaced. Sessions not
+// lent by the pool, or already returned, are ignored.
+func (p *Pool) Put(wd WebDriver) {
+	p.mu.Lock()
+	leased := p.leased[wd]
+	delete(p.leased, wd)
+	p.mu.Unlock()
+	if !leased {
+		if Log != nil {
+			Log.Printf("pool: ignoring session %s, which was not lent by the pool", wd.SessionID())
+		}
+		return
+	}
+
+	if err := TraceSpan(wd, "Pool.Put", func() error { return reset(wd) }); err != nil {
+		if Log != nil {
+			Log.Printf("pool: replacing session %s: %s", wd.SessionID(), err)
+		}
+		wd.Quit()
+		wd = nil
+	}
+	p.release(wd)
+}
+
+// release adds wd to the idle sessions, or quits it if the pool is closed.
+// Since only lent sessions are released, idle always has room for them.
+func (p *Pool) release(wd WebDriver) {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		if wd != nil {
+			wd.Quit()
+		}
+		return
+	}
+	p.idle <- wd
+
+	// Close may have drained the idle sessions in the meantime.
+	p.mu.Lock()
+	closed = p.closed
+	p.mu.Unlock()
+	if closed {
+		p.quitIdle()
+	}
+}
+
+// Close quits the idle sessions. Sessions still lent are quit when they are
+// returned.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	p.mu.Unlock()
+	p.quitIdle()
+}
+
+// quitIdle quits the idle sessions.
+func (p *Pool) quitIdle() {
+	for {
+		select {
+		case wd := <-p.idle:
+			if wd != nil {
+				wd.Quit()
+			}
+		default:
+			return
+		}
+	}
+}
+
+// reset returns a session to the state of a new one.
+func reset(wd WebDriver) error {
+	handles, err := wd.WindowHandles()
+	if err != nil {
+		return err
+	}
+	if len(handles) > 1 {
+		for _, h := range handles[1:] {
+			if err := wd.SwitchWindow(h); err != nil {
+				return err
+			}
+			if err := wd.CloseWindow(h); err != nil {
+				return err
+			}
+		}
+		if err := wd.SwitchWindow(handles[0]); err != nil {
+			return err
+		}
+	}
+	if err := wd.DeleteAllCookies(); err != nil {
+		return err
+	}
+	// Web storage belongs to the page's origin, so clear it before leaving.
+	// Pages such as about:blank have no storage to clear, and fail.
+	wd.LocalStorage().Clear()
+	wd.SessionStorage().Clear()
+	return wd.Get("about:blank")
+}
@@ -0,0 +1,205 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolServer starts a test server answering the commands of Pool, and
+// returns the log of commands received and a count of sessions created.
+func poolServer(healthy func() bool) (log func() []string, sessions func() int) {
+	var mu sync.Mutex
+	var commands []string
+	n := 0
+	record := func(r *http.Request) {
+		mu.Lock()
+		commands = append(commands, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+	}
+
+	mux = http.NewServeMux()
+	server = httptest.NewServer(mux)
+	mux.HandleFunc("/session", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		n++
+		mu.Unlock()
+		fmt.Fprint(w, `{"sessionId": "123"}`)
+	})
+	mux.HandleFunc("/session/123/window_handle", func(w http.ResponseWriter, r *http.Request) {
+		if !healthy() {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"value": {"error": "invalid session id", "message": ""}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status": 0, "value": "w1"}`)
+	})
+	mux.HandleFunc("/session/123/window_handles", func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		fmt.Fprint(w, `{"status": 0, "value": ["w1", "w2"]}`)
+	})
+	mux.HandleFunc("/session/123/window", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		mu.Lock()
+		commands = append(commands, r.Method+" "+r.URL.Path+" "+v["name"])
+		mu.Unlock()
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+	for _, path := range []string{"/session/123/cookie", "/session/123/local_storage", "/session/123/session_storage", "/session/123/url"} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			record(r)
+			fmt.Fprint(w, `{"status": 0}`)
+		})
+	}
+	mux.HandleFunc("/session/123", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	log = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), commands...)
+	}
+	sessions = func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return n
+	}
+	return log, sessions
+}
+
+func TestPool(t *testing.T) {
+	defer teardown()
+	log, sessions := poolServer(func() bool { return true })
+
+	pool, err := NewPool(caps, server.URL, 2)
+	if err != nil {
+		t.Fatalf("NewPool returned error: %v", err)
+	}
+	defer pool.Close()
+	if n := sessions(); n != 2 {
+		t.Errorf("NewPool created %d sessions, want 2", n)
+	}
+
+	a, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pool.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A third Get waits for a session to be returned.
+	got := make(chan WebDriver)
+	go func() {
+		wd, _ := pool.Get()
+		got <- wd
+	}()
+	select {
+	case <-got:
+		t.Fatal("Get returned a session while all were lent")
+	case <-time.After(20 * time.Millisecond):
+	}
+	pool.Put(a)
+	select {
+	case wd := <-got:
+		if wd != a {
+			t.Error("Get did not return the session put back")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get did not return after Put")
+	}
+
+	want := []string{
+		"GET /session/123/window_handles",
+		"POST /session/123/window w2",
+		"DELETE /session/123/window ",
+		"POST /session/123/window w1",
+		"DELETE /session/123/cookie",
+		"DELETE /session/123/local_storage",
+		"DELETE /session/123/session_storage",
+		"POST /session/123/url",
+	}
+	if got := log(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Put sent\n%q\nwant\n%q", got, want)
+	}
+	if n := sessions(); n != 2 {
+		t.Errorf("pool created %d sessions, want 2", n)
+	}
+
+	pool.Put(b)
+	pool.Close()
+	if _, err := pool.Get(); err != ErrPoolClosed {
+		t.Errorf("Get after Close returned %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestPool_PutNotLent(t *testing.T) {
+	defer teardown()
+	poolServer(func() bool { return true })
+
+	pool, err := NewPool(caps, server.URL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewRemote(caps, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wd, err := pool.Get()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		pool.Put(wd)
+		pool.Put(wd)
+		pool.Put(other)
+		if _, err := pool.Get(); err != nil {
+			t.Error(err)
+		}
+		pool.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Put of a session not lent blocked the pool")
+	}
+}
+
+func TestPool_ReplacesUnhealthy(t *testing.T) {
+	defer teardown()
+	var mu sync.Mutex
+	healthy := true
+	_, sessions := poolServer(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return healthy
+	})
+
+	pool, err := NewPool(caps, server.URL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	mu.Lock()
+	healthy = false
+	mu.Unlock()
+	if _, err := pool.Get(); err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if n := sessions(); n != 2 {
+		t.Errorf("got %d sessions, want the unhealthy one replaced", n)
+	}
+}
@@ -0,0 +1,64 @@
+package selenium
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+)
+
+/* Print orientations */
+const (
+	PrintPortrait  = "portrait"
+	PrintLandscape = "landscape"
+)
+
+// PrintPageSize is the size of the printed paper, in centimeters.
+type PrintPageSize struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// PrintMargins are the margins of the printed page, in centimeters.
+type PrintMargins struct {
+	Top    float64 `json:"top"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+	Right  float64 `json:"right"`
+}
+
+// PrintOptions configures PrintPage. Zero fields use the browser's defaults
+// (portrait, scale 1, no background, US letter paper, 1cm margins).
+type PrintOptions struct {
+	// Orientation is PrintPortrait or PrintLandscape.
+	Orientation string `json:"orientation,omitempty"`
+	// Scale is the zoom factor, between 0.1 and 2.
+	Scale float64 `json:"scale,omitempty"`
+	// Background is whether to print background colors and images.
+	Background bool           `json:"background,omitempty"`
+	Page       *PrintPageSize `json:"page,omitempty"`
+	Margin     *PrintMargins  `json:"margin,omitempty"`
+	// PageRanges restricts the printed pages, e.g. "1-3" or "5".
+	PageRanges []string `json:"pageRanges,omitempty"`
+}
+
+func (wd *remoteWebDriver) PrintPage(opts *PrintOptions) (io.Reader, error) {
+	if opts == nil {
+		opts = &PrintOptions{}
+	}
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	r, err := wd.send("POST", wd.url("/session/%s/print", wd.id), data)
+	if err != nil {
+		return nil, err
+	}
+	var pdf string
+	if err := r.readValue(&pdf); err != nil {
+		return nil, err
+	}
+
+	// The remote end returns a base64 encoded PDF document.
+	return base64.NewDecoder(base64.StdEncoding, bytes.NewBufferString(pdf)), nil
+}
@@ -0,0 +1,53 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestPrintPage(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/print", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+
+		var v map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&v)
+		want := map[string]interface{}{
+			"orientation": "landscape",
+			"background":  true,
+			"page":        map[string]interface{}{"width": 21.0, "height": 29.7},
+			"margin":      map[string]interface{}{"top": 0.0, "bottom": 0.0, "left": 0.0, "right": 0.0},
+			"pageRanges":  []interface{}{"1-2"},
+		}
+		if !reflect.DeepEqual(v, want) {
+			t.Errorf("Request body = %+v, want %+v", v, want)
+		}
+
+		// "%PDF-1.4" base64 encoded.
+		fmt.Fprint(w, `{"value": "JVBERi0xLjQ="}`)
+	})
+
+	pdf, err := client.PrintPage(&PrintOptions{
+		Orientation: PrintLandscape,
+		Background:  true,
+		Page:        &PrintPageSize{Width: 21, Height: 29.7},
+		Margin:      &PrintMargins{},
+		PageRanges:  []string{"1-2"},
+	})
+	if err != nil {
+		t.Fatalf("PrintPage returned error: %v", err)
+	}
+	data, err := ioutil.ReadAll(pdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "%PDF-1.4" {
+		t.Errorf("PrintPage returned %q, want %q", data, "%PDF-1.4")
+	}
+}
@@ -0,0 +1,145 @@
+package selenium
+
+import (
+	"fmt"
+)
+
+// nearDistance is the default distance of Near, in CSS pixels.
+const nearDistance = 50
+
+// A relation restricts the elements located by a By to those in a
+// position relative to an element.
+type relation struct {
+	kind     string
+	ref      WebElement
+	distance float64
+}
+
+// relativeScript keeps the candidate elements in the given relations to
+// the reference elements, and returns their indexes, nearest to the first
+// reference element first. Its arguments are the kinds and distances of
+// the relations, then the reference elements, then the candidates.
+const relativeScript = `
+var kinds = arguments[0], distances = arguments[1], n = kinds.length;
+var refs = Array.prototype.slice.call(arguments, 2, 2 + n);
+var candidates = Array.prototype.slice.call(arguments, 2 + n);
+function rect(e) {
+  var r = e.getBoundingClientRect();
+  return {left: r.left, top: r.top, right: r.right, bottom: r.bottom};
+}
+function gap(a, b) {
+  var dx = Math.max(0, a.left - b.right, b.left - a.right);
+  var dy = Math.max(0, a.top - b.bottom, b.top - a.bottom);
+  return Math.sqrt(dx * dx + dy * dy);
+}
+function centerDistance(a, b) {
+  var dx = (a.left + a.right - b.left - b.right) / 2;
+  var dy = (a.top + a.bottom - b.top - b.bottom) / 2;
+  return Math.sqrt(dx * dx + dy * dy);
+}
+var tests = {
+  above: function(c, r) { return c.bottom <= r.top; },
+  below: function(c, r) { return c.top >= r.bottom; },
+  leftOf: function(c, r) { return c.right <= r.left; },
+  rightOf: function(c, r) { return c.left >= r.right; },
+  near: function(c, r, d) { return gap(c, r) <= d; }
+};
+var refRects = refs.map(rect);
+var kept = [];
+for (var i = 0; i < candidates.length; i++) {
+  var c = rect(candidates[i]), ok = true;
+  for (var j = 0; j < n && ok; j++) {
+    ok = candidates[i] !== refs[j] && tests[kinds[j]](c, refRects[j], distances[j]);
+  }
+  if (ok) {
+    kept.push({index: i, distance: centerDistance(c, refRects[0])});
+  }
+}
+kept.sort(function(a, b) { return a.distance - b.distance || a.index - b.index; });
+return kept.map(function(k) { return k.index; });
+`
+
+func (b By) relative(kind string, ref WebElement, distance float64) By {
+	b.relations = append(b.relations[:len(b.relations):len(b.relations)], relation{kind, ref, distance})
+	return b
+}
+
+// Above keeps the elements located by b that are entirely above ref. Like
+// the other relative locators, it sorts the elements by their distance to
+// ref, nearest first.
+func (b By) Above(ref WebElement) By {
+	return b.relative("above", ref, 0)
+}
+
+// Below keeps the elements located by b that are entirely below ref.
+func (b By) Below(ref WebElement) By {
+	return b.relative("below", ref, 0)
+}
+
+// LeftOf keeps the elements located by b that are entirely left of ref.
+func (b By) LeftOf(ref WebElement) By {
+	return b.relative("leftOf", ref, 0)
+}
+
+// RightOf keeps the elements located by b that are entirely right of ref.
+func (b By) RightOf(ref WebElement) By {
+	return b.relative("rightOf", ref, 0)
+}
+
+// Near keeps the elements located by b that are at most distance CSS
+// pixels away from ref. Zero means 50 pixels.
+func (b By) Near(ref WebElement, distance float64) By {
+	if distance == 0 {
+		distance = nearDistance
+	}
+	return b.relative("near", ref, distance)
+}
+
+// keepRelated keeps the elements in the relations of b.
+func (b *By) keepRelated(elems []WebElement) ([]WebElement, error) {
+	if len(elems) == 0 {
+		return elems, nil
+	}
+	ref, ok := unwrapElement(b.relations[0].ref).(*remoteWE)
+	if !ok {
+		return nil, fmt.Errorf("unsupported WebElement implementation %T", b.relations[0].ref)
+	}
+
+	var kinds []string
+	var distances []float64
+	var args []interface{}
+	for _, r := range b.relations {
+		kinds = append(kinds, r.kind)
+		distances = append(distances, r.distance)
+	}
+	args = append(args, kinds, distances)
+	for _, r := range b.relations {
+		args = append(args, r.ref)
+	}
+	for _, e := range elems {
+		args = append(args, e)
+	}
+
+	res, err := ref.parent.ExecuteScript(relativeScript, args)
+	if err != nil {
+		return nil, err
+	}
+	indexes, _ := res.([]interface{})
+	var kept []WebElement
+	for _, i := range indexes {
+		n, ok := i.(float64)
+		if !ok || int(n) < 0 || int(n) >= len(elems) {
+			return nil, fmt.Errorf("unexpected relative locator script result %v", res)
+		}
+		kept = append(kept, elems[int(n)])
+	}
+	return kept, nil
+}
+
+func (r relation) String() string {
+	id, _ := elementID(r.ref)
+	if r.kind == "near" {
+		return fmt.Sprintf("near(%g) element %s", r.distance, id)
+	}
+	return fmt.Sprintf("%s element %s", r.kind, id)
+}
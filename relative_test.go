@@ -0,0 +1,76 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestBy_Relative(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "ref"}}`)
+	})
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "a"}, {"ELEMENT": "b"}, {"ELEMENT": "c"}]}`)
+	})
+	var args []interface{}
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Script string
+			Args   []interface{}
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		if v.Script != relativeScript {
+			t.Error("unexpected script")
+		}
+		args = v.Args
+		fmt.Fprint(w, `{"status": 0, "value": [2, 0]}`)
+	})
+
+	ref, err := client.Q("#ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := ByCSS("input").Below(ref).Near(ref, 0)
+	if got, want := b.String(), `css selector "input" below element ref near(50) element ref`; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+	elems, err := b.FindElements(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ref1 := map[string]interface{}{"ELEMENT": "ref"}
+	wantArgs := []interface{}{
+		[]interface{}{"below", "near"},
+		[]interface{}{0.0, 50.0},
+		ref1, ref1,
+		map[string]interface{}{"ELEMENT": "a"},
+		map[string]interface{}{"ELEMENT": "b"},
+		map[string]interface{}{"ELEMENT": "c"},
+	}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("script args = %v, want %v", args, wantArgs)
+	}
+	var ids []string
+	for _, e := range elems {
+		id, _ := elementID(e)
+		ids = append(ids, id)
+	}
+	if want := []string{"c", "a"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got elements %v, want %v", ids, want)
+	}
+
+	e, err := ByCSS("input").RightOf(ref).Nth(1).FindElement(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := elementID(e); id != "a" {
+		t.Errorf("Nth(1) returned element %s, want a", id)
+	}
+}
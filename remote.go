@@ -46,6 +46,7 @@ var errorCodes = map[int]string{
 	28: "script timeout",
 	29: "invalid element coordinates",
 	32: "invalid selector",
+	33: "session not created",
 }
 
 const (
@@ -57,6 +58,34 @@ const (
 type remoteWebDriver struct {
 	id, executor string
 	capabilities Capabilities
+	fileDetector FileDetector
+	// scriptStorage is set once the remote end is found not to implement
+	// the web storage endpoints, see webStorage.
+	scriptStorage bool
+	// geolocation is the position set by SetGeolocation through a script.
+	geolocation *Location
+	// w3c is whether the session speaks the W3C WebDriver dialect rather
+	// than the JSON Wire Protocol.
+	w3c bool
+	// sessionCaps are the capabilities returned for the session by NewSession.
+	sessionCaps Capabilities
+	// sessionRetry, if set, is how NewRemote retries failed session
+	// creation, within sessionCtx.
+	sessionRetry *Backoff
+	sessionCtx   context.Context
+	// staleRecovery is whether elements find themselves again once stale,
+	// see WithStaleElementRecovery.
+	staleRecovery bool
+	// noSelectorValidation is whether CSS selectors and XPath expressions
+	// are sent unchecked, see WithoutSelectorValidation.
+	noSelectorValidation bool
+	// middleware wraps every command, see WithMiddleware.
+	middleware []Middleware
+	// tracer records the commands in spans, see WithTracer; spans are the
+	// spans started by TraceSpan and not ended yet.
+	tracer Tracer
+	spanMu sync.Mutex
+	spans  []Span
 	// FIXME
 	// profile             BrowserProfile
 	ctx context.Context
@@ -92,7 +121,7 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 	case <-wd.ctx.Done():
 		err = ErrCanceled
 		wd.ctx = context.Background()
-		_ = wd.Quit()
+		wd.quitCanceled()
 		return
 	default:
 	}
@@ -101,12 +130,30 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 		case <-wd.ctx.Done():
 			err = ErrCanceled
 			wd.ctx = context.Background()
-			_ = wd.Quit()
+			wd.quitCanceled()
 			return
 		default:
 		}
 	}()
 
+	cmd := &Command{
+		Name:      wd.commandName(url),
+		Method:    method,
+		URL:       url,
+		Params:    data,
+		SessionID: wd.id,
+	}
+	h := Handler(wd.do)
+	for i := len(wd.middleware) - 1; i >= 0; i-- {
+		h = wd.middleware[i](h)
+	}
+	return h(cmd)
+}
+
+// do sends a command to the remote end and returns the body of its reply.
+// It is the innermost Handler of the middleware chain.
+func (wd *remoteWebDriver) do(cmd *Command) ([]byte, error) {
+	method, url, data := cmd.Method, cmd.URL, cmd.Params
 	if Log != nil {
 		Log.Printf("-> %s %s [%d bytes]", method, url, len(data))
 	}
@@ -139,7 +186,7 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 		}
 	}
 
-	buf, err = ioutil.ReadAll(res.Body)
+	buf, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
@@ -151,14 +198,12 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 		reply := new(reply)
 		err := json.Unmarshal(buf, reply)
 		if err != nil {
-			return nil, errors.New(fmt.Sprintf("Bad server reply status: %s", res.Status))
-		}
-		message, ok := errorCodes[reply.Status]
-		if !ok {
-			message = fmt.Sprintf("unknown error - %d", reply.Status)
+			return nil, &serverError{
+				httpStatus: res.StatusCode,
+				message:    fmt.Sprintf("Bad server reply status: %s", res.Status),
+			}
 		}
-
-		return nil, errors.New(message)
+		return nil, reply.error(res.StatusCode)
 	}
 
 	/* Some bug(?) in Selenium gets us nil values in output, json.Unmarshal is
@@ -172,12 +217,7 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 		}
 
 		if reply.Status != SUCCESS {
-			message, ok := errorCodes[reply.Status]
-			if !ok {
-				message = fmt.Sprintf("unknown error - %d", reply.Status)
-			}
-
-			return nil, errors.New(message)
+			return nil, reply.error(res.StatusCode)
 		}
 		return buf, err
 	}
@@ -186,6 +226,14 @@ func (wd *remoteWebDriver) execute(method, url string, data []byte) (buf []byte,
 	return buf, nil
 }
 
+// quitCanceled ends the session after its context was cancelled, unless
+// the session was not created yet.
+func (wd *remoteWebDriver) quitCanceled() {
+	if wd.id != "" {
+		_ = wd.Quit()
+	}
+}
+
 var httpClient = http.Client{
 	// WebDriver requires that all requests have an 'Accept: application/json' header. We must add
 	// it here because by default net/http will not include that header when following redirects.
@@ -214,17 +262,74 @@ func (r *reply) readValue(v interface{}) error {
 	return json.Unmarshal(r.Value, v)
 }
 
+// error returns the error reported in a failed reply. JSON Wire servers
+// report a numeric status, W3C servers an error code in the value; both
+// use the same error names.
+func (r *reply) error(httpStatus int) error {
+	e := &serverError{httpStatus: httpStatus, decoded: true}
+	var v struct {
+		Error   string
+		Message string
+	}
+	json.Unmarshal(r.Value, &v)
+	e.detail = v.Message
+	if r.Status == SUCCESS && v.Error != "" {
+		e.message = v.Error
+		return e
+	}
+	message, ok := errorCodes[r.Status]
+	if !ok {
+		message = fmt.Sprintf("unknown error - %d", r.Status)
+	}
+	e.message = message
+	return e
+}
+
+// serverError is an error reported by the remote end.
+type serverError struct {
+	// httpStatus is the HTTP status code of the reply.
+	httpStatus int
+	// decoded is whether the reply body was a wire protocol error.
+	decoded bool
+	message string
+	// detail is the message explaining the error, if the remote end sent
+	// one.
+	detail string
+}
+
+func (e *serverError) Error() string {
+	return e.message
+}
+
+// isUnknownCommand reports whether err indicates that the remote end does
+// not implement the command, so that callers can fall back to another
+// endpoint.
+func isUnknownCommand(err error) bool {
+	e, ok := err.(*serverError)
+	if !ok {
+		return false
+	}
+	if e.message == "unknown command" || e.message == "unknown method" {
+		return true
+	}
+	return !e.decoded && (e.httpStatus == http.StatusNotFound || e.httpStatus == http.StatusMethodNotAllowed)
+}
+
 // An active session.
 type Session struct {
 	Id           string
 	Capabilities Capabilities
 }
 
+// A RemoteOption configures a WebDriver created by NewRemote.
+type RemoteOption func(*remoteWebDriver)
+
 /* Create new remote client, this will also start a new session.
    capabilities - the desired capabilities, see http://goo.gl/SNlAk
    executor - the URL to the Selenim server
+   opts - options that configure the client, see RemoteOption
 */
-func NewRemote(capabilities Capabilities, executor string) (WebDriver, error) {
+func NewRemote(capabilities Capabilities, executor string, opts ...RemoteOption) (WebDriver, error) {
 	if executor == "" {
 		executor = defaultExecutor
 	}
@@ -234,16 +339,64 @@ func NewRemote(capabilities Capabilities, executor string) (WebDriver, error) {
 		capabilities: capabilities,
 		ctx:          context.Background(),
 	}
+	for _, opt := range opts {
+		opt(wd)
+	}
 	// FIXME: Handle profile
 
-	_, err := wd.NewSession()
-	if err != nil {
+	if err := wd.createSession(); err != nil {
 		return nil, err
 	}
 
 	return wd, nil
 }
 
+/*
+Attach to an existing session, e.g. one left open by another process.
+
+	executor - the URL to the Selenim server
+	sessionID - the ID of the session
+	opts - options that configure the client, see RemoteOption
+*/
+func Attach(executor, sessionID string, opts ...RemoteOption) (WebDriver, error) {
+	if executor == "" {
+		executor = defaultExecutor
+	}
+
+	wd := &remoteWebDriver{
+		id:       sessionID,
+		executor: executor,
+		ctx:      context.Background(),
+	}
+	for _, opt := range opts {
+		opt(wd)
+	}
+
+	r, err := wd.send("GET", wd.url("/session/%s", wd.id), nil)
+	switch {
+	case err == nil && r != nil && r.SessionId != "":
+		// Only JSON Wire replies name the session.
+		r.readValue(&wd.sessionCaps)
+	case err == nil:
+		wd.w3c = true
+		if r != nil {
+			r.readValue(&wd.sessionCaps)
+		}
+	case isUnknownCommand(err):
+		// W3C remote ends don't return the capabilities of a session, but
+		// fail any command of an unknown one.
+		if _, err := wd.send("GET", wd.url("/session/%s/timeouts", wd.id), nil); err != nil {
+			return nil, err
+		}
+		wd.w3c = true
+	default:
+		return nil, err
+	}
+	wd.capabilities = wd.sessionCaps
+
+	return wd, nil
+}
+
 func (wd *remoteWebDriver) stringCommand(urlTemplate string) (v string, err error) {
 	var r *reply
 	if r, err = wd.send("GET", wd.url(urlTemplate, wd.id), nil); err == nil {
@@ -313,15 +466,40 @@ func (wd *remoteWebDriver) NewSession() (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if r == nil {
+		return "", errors.New("empty new session reply")
+	}
 	wd.id = r.SessionId
+	if wd.id == "" {
+		// W3C remote ends return the session ID in the value.
+		var v struct {
+			SessionId    string
+			Capabilities Capabilities
+		}
+		if err := r.readValue(&v); err != nil || v.SessionId == "" {
+			return "", errors.New("new session reply has no session ID")
+		}
+		wd.id = v.SessionId
+		wd.w3c = true
+		wd.sessionCaps = v.Capabilities
+	} else if len(r.Value) > 0 {
+		r.readValue(&wd.sessionCaps)
+	}
 
-	return r.SessionId, nil
+	return wd.id, nil
+}
+
+func (wd *remoteWebDriver) SessionID() string {
+	return wd.id
 }
 
 func (wd *remoteWebDriver) Capabilities() (v Capabilities, err error) {
 	var r *reply
 	if r, err = wd.send("GET", wd.url("/session/%s", wd.id), nil); err == nil {
 		r.readValue(&v)
+	} else if isUnknownCommand(err) && wd.sessionCaps != nil {
+		// W3C remote ends only return the capabilities on NewSession.
+		return wd.sessionCaps, nil
 	}
 	return
 }
@@ -416,11 +594,45 @@ func (wd *remoteWebDriver) PageSource() (string, error) {
 	return wd.stringCommand("/session/%s/source")
 }
 
+// webElementKey is the key of element references in the W3C dialect.
+const webElementKey = "element-6066-11e4-a52e-4f735466cecf"
+
 type element struct {
-	Element string `json:"ELEMENT"`
+	Element string `json:"ELEMENT,omitempty"`
+	W3C     string `json:"element-6066-11e4-a52e-4f735466cecf,omitempty"`
+}
+
+func (e *element) id() string {
+	if e.Element != "" {
+		return e.Element
+	}
+	return e.W3C
+}
+
+// elementRef returns a reference to the element with the given ID, in the
+// session's dialect.
+func (wd *remoteWebDriver) elementRef(id string) *element {
+	if wd.w3c {
+		return &element{W3C: id}
+	}
+	return &element{Element: id}
+}
+
+// elementID returns the ID of a WebElement returned by this package,
+// possibly wrapped, see WrapsElement.
+func elementID(e WebElement) (string, error) {
+	if v, ok := unwrapElement(e).(*remoteWE); ok {
+		return v.currentID(), nil
+	}
+	return "", fmt.Errorf("unsupported WebElement implementation %T", e)
 }
 
 func (wd *remoteWebDriver) find(by, value, suffix, url string) (r *reply, err error) {
+	if !wd.noSelectorValidation {
+		if err := validateSelector(by, value); err != nil {
+			return nil, err
+		}
+	}
 	params := map[string]string{"using": by, "value": value}
 	var data []byte
 	if data, err = json.Marshal(params); err == nil {
@@ -439,12 +651,14 @@ func decodeElement(wd *remoteWebDriver, r *reply) WebElement {
 	if err := r.readValue(&elem); err != nil {
 		panic(err.Error() + ": " + string(r.Value))
 	}
-	return &remoteWE{parent: wd, id: elem.Element}
+	return &remoteWE{parent: wd, id: elem.id()}
 }
 
 func (wd *remoteWebDriver) FindElement(by, value string) (WebElement, error) {
 	if res, err := wd.find(by, value, "", ""); err == nil {
-		return decodeElement(wd, res), nil
+		elem := decodeElement(wd, res)
+		wd.located([]WebElement{elem}, nil, by, value, false)
+		return elem, nil
 	} else {
 		return nil, err
 	}
@@ -456,14 +670,16 @@ func decodeElements(wd *remoteWebDriver, r *reply) (welems []WebElement) {
 		panic(err.Error() + ": " + string(r.Value))
 	}
 	for _, elem := range elems {
-		welems = append(welems, &remoteWE{wd, elem.Element})
+		welems = append(welems, &remoteWE{parent: wd, id: elem.id()})
 	}
 	return
 }
 
 func (wd *remoteWebDriver) FindElements(by, value string) ([]WebElement, error) {
 	if res, err := wd.find(by, value, "s", ""); err == nil {
-		return decodeElements(wd, res), nil
+		elems := decodeElements(wd, res)
+		wd.located(elems, nil, by, value, true)
+		return elems, nil
 	} else {
 		return nil, err
 	}
@@ -649,8 +865,10 @@ func (wd *remoteWebDriver) execScript(script string, args []interface{}, suffix
 		args = []interface{}{}
 	}
 	for i, arg := range args {
-		if v, ok := arg.(*remoteWE); ok {
-			args[i] = &element{Element: v.id}
+		if e, ok := arg.(WebElement); ok {
+			if v, ok := unwrapElement(e).(*remoteWE); ok {
+				args[i] = wd.elementRef(v.currentID())
+			}
 		}
 	}
 	params := map[string]interface{}{
@@ -697,59 +915,102 @@ func (wd *remoteWebDriver) T(t TestingT) WebDriverT {
 
 type remoteWE struct {
 	parent *remoteWebDriver
-	id     string
+	// idMu guards id, which changes when a stale element is found again.
+	idMu sync.Mutex
+	id   string
+	// loc is how the element was found, if the session recovers stale
+	// elements, see WithStaleElementRecovery. It is set once, when the
+	// element is found.
+	loc *locator
+}
+
+// currentID returns the ID of the element.
+func (elem *remoteWE) currentID() string {
+	elem.idMu.Lock()
+	defer elem.idMu.Unlock()
+	return elem.id
+}
+
+// voidCommand sends the command at urlTemplate, given the element ID.
+func (elem *remoteWE) voidCommand(urlTemplate string, params interface{}) error {
+	return elem.retry(func() error {
+		return elem.parent.voidCommand(fmt.Sprintf(urlTemplate, elem.currentID()), params)
+	})
+}
+
+// stringCommand sends the command at urlTemplate, given the element ID
+// and args.
+func (elem *remoteWE) stringCommand(urlTemplate string, args ...interface{}) (v string, err error) {
+	err = elem.retry(func() (err error) {
+		v, err = elem.parent.stringCommand(fmt.Sprintf(urlTemplate, append([]interface{}{elem.currentID()}, args...)...))
+		return
+	})
+	return
 }
 
 func (elem *remoteWE) Click() error {
-	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/click", elem.id)
-	return elem.parent.voidCommand(urlTemplate, nil)
+	return elem.voidCommand("/session/%%s/element/%s/click", nil)
 }
 
 func (elem *remoteWE) SendKeys(keys string) error {
+	if fd := elem.parent.fileDetector; fd != nil {
+		if path := fd(keys); path != "" {
+			remotePath, err := elem.parent.UploadFile(path)
+			if err != nil {
+				return err
+			}
+			keys = remotePath
+		}
+	}
 	chars := make([]string, len(keys))
 	for i, c := range keys {
 		chars[i] = string(c)
 	}
 	params := map[string][]string{"value": chars}
-	urltmpl := fmt.Sprintf("/session/%%s/element/%s/value", elem.id)
-	return elem.parent.voidCommand(urltmpl, params)
+	return elem.voidCommand("/session/%%s/element/%s/value", params)
 }
 
 func (elem *remoteWE) TagName() (string, error) {
-	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/name", elem.id)
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.stringCommand("/session/%%s/element/%s/name")
 }
 
 func (elem *remoteWE) Text() (string, error) {
-	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/text", elem.id)
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.stringCommand("/session/%%s/element/%s/text")
 }
 
 func (elem *remoteWE) Submit() error {
-	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/submit", elem.id)
-	return elem.parent.voidCommand(urlTemplate, nil)
+	return elem.voidCommand("/session/%%s/element/%s/submit", nil)
 }
 
 func (elem *remoteWE) Clear() error {
-	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/clear", elem.id)
-	return elem.parent.voidCommand(urlTemplate, nil)
+	return elem.voidCommand("/session/%%s/element/%s/clear", nil)
 }
 
 func (elem *remoteWE) MoveTo(xOffset, yOffset int) error {
-	params := map[string]interface{}{
-		"element": elem.id,
-		"xoffset": xOffset,
-		"yoffset": yOffset,
-	}
-	return elem.parent.voidCommand("/session/%s/moveto", params)
+	return elem.retry(func() error {
+		params := map[string]interface{}{
+			"element": elem.currentID(),
+			"xoffset": xOffset,
+			"yoffset": yOffset,
+		}
+		return elem.parent.voidCommand("/session/%s/moveto", params)
+	})
 }
 
-func (elem *remoteWE) FindElement(by, value string) (WebElement, error) {
-	res, err := elem.parent.find(by, value, "", fmt.Sprintf("/session/%%s/element/%s/element", elem.id))
+func (elem *remoteWE) FindElement(by, value string) (found WebElement, err error) {
+	err = elem.retry(func() error {
+		res, err := elem.parent.find(by, value, "", fmt.Sprintf("/session/%%s/element/%s/element", elem.currentID()))
+		if err != nil {
+			return err
+		}
+		found = decodeElement(elem.parent, res)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return decodeElement(elem.parent, res), nil
+	elem.parent.located([]WebElement{found}, elem, by, value, false)
+	return found, nil
 }
 
 func (elem *remoteWE) Q(sel string) (WebElement, error) {
@@ -760,17 +1021,29 @@ func (elem *remoteWE) QAll(sel string) ([]WebElement, error) {
 	return elem.FindElements(ByCSSSelector, sel)
 }
 
-func (elem *remoteWE) FindElements(by, value string) ([]WebElement, error) {
-	res, err := elem.parent.find(by, value, "s", fmt.Sprintf("/session/%%s/element/%s/element", elem.id))
+func (elem *remoteWE) FindElements(by, value string) (found []WebElement, err error) {
+	err = elem.retry(func() error {
+		res, err := elem.parent.find(by, value, "s", fmt.Sprintf("/session/%%s/element/%s/element", elem.currentID()))
+		if err != nil {
+			return err
+		}
+		found = decodeElements(elem.parent, res)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return decodeElements(elem.parent, res), nil
+	elem.parent.located(found, elem, by, value, true)
+	return found, nil
 }
 
-func (elem *remoteWE) boolQuery(urlTemplate string) (bool, error) {
-	url := fmt.Sprintf(urlTemplate, elem.id)
-	return elem.parent.boolCommand(url)
+func (elem *remoteWE) boolQuery(urlTemplate string) (v bool, err error) {
+	err = elem.retry(func() (err error) {
+		url := fmt.Sprintf(urlTemplate, elem.currentID())
+		v, err = elem.parent.boolCommand(url)
+		return
+	})
+	return
 }
 
 // Porperties
@@ -787,20 +1060,20 @@ func (elem *remoteWE) IsDisplayed() (bool, error) {
 }
 
 func (elem *remoteWE) GetAttribute(name string) (string, error) {
-	template := "/session/%%s/element/%s/attribute/%s"
-	urlTemplate := fmt.Sprintf(template, elem.id, name)
-
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.stringCommand("/session/%%s/element/%s/attribute/%s", name)
 }
 
 func (elem *remoteWE) location(suffix string) (pt *Point, err error) {
 	wd := elem.parent
-	path := "/session/%s/element/%s/location" + suffix
-	url := wd.url(path, wd.id, elem.id)
-	var r *reply
-	if r, err = wd.send("GET", url, nil); err == nil {
-		err = r.readValue(&pt)
-	}
+	err = elem.retry(func() error {
+		path := "/session/%s/element/%s/location" + suffix
+		url := wd.url(path, wd.id, elem.currentID())
+		r, err := wd.send("GET", url, nil)
+		if err == nil {
+			err = r.readValue(&pt)
+		}
+		return err
+	})
 	return
 }
 
@@ -814,17 +1087,19 @@ func (elem *remoteWE) LocationInView() (*Point, error) {
 
 func (elem *remoteWE) Size() (sz *Size, err error) {
 	wd := elem.parent
-	url := wd.url("/session/%s/element/%s/size", wd.id, elem.id)
-	var r *reply
-	if r, err = wd.send("GET", url, nil); err == nil {
-		err = r.readValue(&sz)
-	}
+	err = elem.retry(func() error {
+		url := wd.url("/session/%s/element/%s/size", wd.id, elem.currentID())
+		r, err := wd.send("GET", url, nil)
+		if err == nil {
+			err = r.readValue(&sz)
+		}
+		return err
+	})
 	return
 }
 
 func (elem *remoteWE) CSSProperty(name string) (string, error) {
-	urlTemplate := fmt.Sprintf("/session/%%s/element/%s/css/%s", elem.id, name)
-	return elem.parent.stringCommand(urlTemplate)
+	return elem.stringCommand("/session/%%s/element/%s/css/%s", name)
 }
 
 func (elem *remoteWE) T(t TestingT) WebElementT {
@@ -0,0 +1,236 @@
+package selenium
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Backoff describes the exponentially growing delays between retries.
+type Backoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max caps the delay between retries, if not zero.
+	Max time.Duration
+	// Multiplier is the factor by which the delay grows after each retry.
+	// Zero means 2.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction of it, so that
+	// clients failing together don't retry together.
+	Jitter float64
+	// MaxAttempts bounds the number of attempts, if not zero.
+	MaxAttempts int
+}
+
+// DefaultBackoff is a Backoff suited to waiting for capacity on a grid. It
+// gives up after about three minutes; use WithContext to wait longer or
+// shorter.
+var DefaultBackoff = Backoff{
+	Initial:     500 * time.Millisecond,
+	Max:         30 * time.Second,
+	Multiplier:  2,
+	Jitter:      0.2,
+	MaxAttempts: 12,
+}
+
+// delay returns the delay before retry number attempt, counting from 0.
+func (b *Backoff) delay(attempt int) time.Duration {
+	m := b.Multiplier
+	if m == 0 {
+		m = 2
+	}
+	d := float64(b.Initial) * math.Pow(m, float64(attempt))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		d += d * b.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// WithSessionRetry makes NewRemote retry session creation after failures
+// that may go away, such as network errors, gateway errors and a grid
+// without free slots, waiting between attempts as b says. Failures that
+// won't go away, such as invalid capabilities, are returned at once.
+func WithSessionRetry(b Backoff) RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.sessionRetry = &b
+	}
+}
+
+// WithContext bounds session creation by NewRemote, including its
+// retries, by ctx.
+func WithContext(ctx context.Context) RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.sessionCtx = ctx
+	}
+}
+
+// createSession creates the session for NewRemote, retrying as configured.
+func (wd *remoteWebDriver) createSession() error {
+	ctx := wd.sessionCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	wd.ctx = ctx
+	defer func() { wd.ctx = context.Background() }()
+
+	for attempt := 0; ; attempt++ {
+		_, err := wd.NewSession()
+		if err == nil || wd.sessionRetry == nil || !isRetryable(err) {
+			return err
+		}
+		if max := wd.sessionRetry.MaxAttempts; max > 0 && attempt+1 >= max {
+			return err
+		}
+		d := wd.sessionRetry.delay(attempt)
+		if Log != nil {
+			Log.Printf("new session failed, retrying in %s: %s", d, err)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(d):
+		}
+	}
+}
+
+// capacityErrors are fragments of the messages of "session not created"
+// errors reported by a grid that has no free slot for the session.
+var capacityErrors = []string{
+	"timed out",
+	"timeout",
+	"no available",
+	"no free",
+	"capacity",
+	"queue",
+	"busy",
+}
+
+// capabilityErrors are fragments of the messages of "session not created"
+// errors caused by capabilities that no node can satisfy.
+var capabilityErrors = []string{
+	"capabilit",
+	"invalid argument",
+	"not supported",
+	"unsupported",
+	"unable to find provider",
+	"no nodes support",
+}
+
+// isRetryable reports whether an error creating a session may go away if
+// the request is sent again.
+func isRetryable(err error) bool {
+	if isTransient(err) {
+		return true
+	}
+	e, ok := err.(*serverError)
+	if !ok || e.message != "session not created" {
+		return false
+	}
+	detail := strings.ToLower(e.detail)
+	for _, s := range capabilityErrors {
+		if strings.Contains(detail, s) {
+			return false
+		}
+	}
+	for _, s := range capacityErrors {
+		if strings.Contains(detail, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransient reports whether err is a timeout, a refused or reset
+// connection, a connection closed early or a gateway error, after which any
+// command may succeed if sent again. Other transport errors, such as an
+// unsupported URL scheme or an untrusted certificate, are permanent.
+func isTransient(err error) bool {
+	if err == ErrCanceled {
+		return false
+	}
+	if e, ok := err.(*url.Error); ok {
+		err = e.Err
+	}
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || err == io.EOF {
+		return true
+	}
+	if e, ok := err.(*serverError); ok {
+		switch e.httpStatus {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+	return false
+}
+
+// A RetryPolicy says which commands to send again after transient
+// failures, network errors and gateway errors, and how long to wait
+// before.
+type RetryPolicy struct {
+	Backoff
+	// Idempotent reports whether a command may be sent again, i.e. whether
+	// sending it twice has the same effect as sending it once. If nil,
+	// IsIdempotent is used.
+	Idempotent func(cmd *Command) bool
+}
+
+// defaultRetryAttempts bounds the attempts of a RetryPolicy without
+// MaxAttempts.
+const defaultRetryAttempts = 3
+
+// IsIdempotent reports whether cmd only reads state: GET commands and
+// finds. Commands such as Click and SendKeys are not.
+func IsIdempotent(cmd *Command) bool {
+	if cmd.Method == "GET" {
+		return true
+	}
+	return cmd.Method == "POST" && (strings.HasSuffix(cmd.Name, "/element") || strings.HasSuffix(cmd.Name, "/elements"))
+}
+
+// WithRetryPolicy makes the WebDriver send commands again after transient
+// failures, as p says, logging each retry. Up to 3 attempts are made if
+// p.MaxAttempts is zero.
+func WithRetryPolicy(p RetryPolicy) RemoteOption {
+	if p.Idempotent == nil {
+		p.Idempotent = IsIdempotent
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = defaultRetryAttempts
+	}
+	return func(wd *remoteWebDriver) {
+		wd.middleware = append(wd.middleware, func(next Handler) Handler {
+			return func(cmd *Command) ([]byte, error) {
+				for attempt := 0; ; attempt++ {
+					reply, err := next(cmd)
+					if err == nil || attempt+1 >= p.MaxAttempts || !isTransient(err) || !p.Idempotent(cmd) {
+						return reply, err
+					}
+					d := p.delay(attempt)
+					if Log != nil {
+						Log.Printf("retrying %s %s in %s: %s", cmd.Method, cmd.URL, d, err)
+					}
+					select {
+					case <-wd.ctx.Done():
+						return reply, err
+					case <-time.After(d):
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package selenium
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var fastBackoff = Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}
+
+// sessionServer answers new session requests with the given replies in
+// turn, then with a session.
+func sessionServer(replies ...func(w http.ResponseWriter)) (*httptest.Server, *int) {
+	attempts := new(int)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*attempts++
+		if *attempts <= len(replies) {
+			replies[*attempts-1](w)
+			return
+		}
+		fmt.Fprint(w, `{"sessionId": "123"}`)
+	}))
+	return s, attempts
+}
+
+func replyStatus(status int, body string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestNewRemote_SessionRetry(t *testing.T) {
+	s, attempts := sessionServer(
+		replyStatus(http.StatusServiceUnavailable, "grid restarting"),
+		replyStatus(http.StatusInternalServerError, `{"value": {"error": "session not created", "message": "Could not start a new session. New session request timed out"}}`),
+	)
+	defer s.Close()
+
+	wd, err := NewRemote(caps, s.URL, WithSessionRetry(fastBackoff))
+	if err != nil {
+		t.Fatalf("NewRemote returned error: %v", err)
+	}
+	if *attempts != 3 {
+		t.Errorf("got %d attempts, want 3", *attempts)
+	}
+	if id := wd.(*remoteWebDriver).id; id != "123" {
+		t.Errorf("got session ID %q, want 123", id)
+	}
+}
+
+func TestNewRemote_SessionRetryPermanent(t *testing.T) {
+	for _, body := range []string{
+		`{"value": {"error": "invalid argument", "message": "bad capabilities"}}`,
+		`{"value": {"error": "session not created", "message": "No nodes support the capabilities in the request"}}`,
+	} {
+		s, attempts := sessionServer(replyStatus(http.StatusInternalServerError, body))
+		_, err := NewRemote(caps, s.URL, WithSessionRetry(fastBackoff))
+		s.Close()
+		if err == nil {
+			t.Errorf("NewRemote after %s returned no error", body)
+		}
+		if *attempts != 1 {
+			t.Errorf("got %d attempts after %s, want 1", *attempts, body)
+		}
+	}
+}
+
+func TestNewRemote_SessionRetryBounds(t *testing.T) {
+	unavailable := replyStatus(http.StatusServiceUnavailable, "")
+	var replies []func(w http.ResponseWriter)
+	for i := 0; i < 1000; i++ {
+		replies = append(replies, unavailable)
+	}
+
+	s, attempts := sessionServer(replies...)
+	defer s.Close()
+	b := fastBackoff
+	b.MaxAttempts = 4
+	if _, err := NewRemote(caps, s.URL, WithSessionRetry(b)); err == nil {
+		t.Error("NewRemote returned no error")
+	}
+	if *attempts != 4 {
+		t.Errorf("got %d attempts, want 4", *attempts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewRemote(caps, s.URL, WithSessionRetry(fastBackoff), WithContext(ctx)); err == nil {
+		t.Error("NewRemote with an expired context returned no error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("NewRemote returned after %s, want about 50ms", d)
+	}
+}
+
+func TestNewRemote_SessionRetryTransport(t *testing.T) {
+	defer func(l *log.Logger) { Log = l }(Log)
+	var logged bytes.Buffer
+	Log = log.New(&logged, "", 0)
+
+	b := fastBackoff
+	b.MaxAttempts = 3
+	if _, err := NewRemote(caps, "foo://localhost/wd/hub", WithSessionRetry(b)); err == nil {
+		t.Fatal("NewRemote with an unsupported scheme returned no error")
+	}
+	if n := strings.Count(logged.String(), "retrying "); n != 0 {
+		t.Errorf("logged %d retries after a permanent transport error, want 0:\n%s", n, logged.String())
+	}
+
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+	logged.Reset()
+	if _, err := NewRemote(caps, s.URL, WithSessionRetry(b)); err == nil {
+		t.Fatal("NewRemote with a closed server returned no error")
+	}
+	if n := strings.Count(logged.String(), "retrying "); n != 2 {
+		t.Errorf("logged %d retries after connection refused, want 2:\n%s", n, logged.String())
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if got := b.delay(attempt); got != want*time.Millisecond {
+			t.Errorf("delay(%d) = %s, want %s", attempt, got, want*time.Millisecond)
+		}
+	}
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.delay(0); d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("delay with jitter = %s, want within 50ms of 100ms", d)
+		}
+	}
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	defer func(l *log.Logger) { Log = l }(Log)
+	var logged bytes.Buffer
+	Log = log.New(&logged, "", 0)
+
+	setup()
+	defer teardown()
+
+	failFirst := func(n int, value string) (http.HandlerFunc, *int) {
+		calls := new(int)
+		return func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			if *calls <= n {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			fmt.Fprintf(w, `{"status": 0, "value": %s}`, value)
+		}, calls
+	}
+	title, titleCalls := failFirst(2, `"ok"`)
+	mux.HandleFunc("/session/123/title", title)
+	find, findCalls := failFirst(1, `{"ELEMENT": "e"}`)
+	mux.HandleFunc("/session/123/element", find)
+	click, clickCalls := failFirst(1, `null`)
+	mux.HandleFunc("/session/123/element/e/click", click)
+	source, sourceCalls := failFirst(5, `""`)
+	mux.HandleFunc("/session/123/source", source)
+
+	wd, err := NewRemote(caps, server.URL, WithRetryPolicy(RetryPolicy{Backoff: fastBackoff}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := wd.Title(); err != nil || got != "ok" || *titleCalls != 3 {
+		t.Errorf("Title returned %q, %v after %d calls, want ok after 3", got, err, *titleCalls)
+	}
+	elem, err := wd.FindElement(ById, "e")
+	if err != nil || *findCalls != 2 {
+		t.Fatalf("FindElement returned %v after %d calls, want success after 2", err, *findCalls)
+	}
+	if err := elem.Click(); err == nil || *clickCalls != 1 {
+		t.Errorf("Click returned %v after %d calls, want an error after 1", err, *clickCalls)
+	}
+	if _, err := wd.PageSource(); err == nil || *sourceCalls != 3 {
+		t.Errorf("PageSource returned %v after %d calls, want an error after 3", err, *sourceCalls)
+	}
+	if n := strings.Count(logged.String(), "retrying "); n != 5 {
+		t.Errorf("logged %d retries, want 5:\n%s", n, logged.String())
+	}
+}
@@ -0,0 +1,439 @@
+package selenium
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A SelectorError is a syntax error in a CSS selector or XPath
+// expression, found before sending it to the remote end.
+type SelectorError struct {
+	// By is ByCSSSelector or ByXPATH.
+	By       string
+	Selector string
+	// Offset is the byte offset of the error in Selector.
+	Offset int
+	Msg    string
+}
+
+func (e *SelectorError) Error() string {
+	// Whitespace is shown as spaces to keep the caret under the error.
+	shown := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\r' || r == '\t' || r == '\f' {
+			return ' '
+		}
+		return r
+	}, e.Selector)
+	caret := strings.Repeat(" ", utf8.RuneCountInString(e.Selector[:e.Offset])) + "^"
+	return fmt.Sprintf("invalid %s: %s at offset %d\n\t%s\n\t%s", e.By, e.Msg, e.Offset, shown, caret)
+}
+
+// WithoutSelectorValidation makes the WebDriver send CSS selectors and
+// XPath expressions to the remote end without checking their syntax
+// first, e.g. to use syntax the validators don't know of.
+func WithoutSelectorValidation() RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.noSelectorValidation = true
+	}
+}
+
+// validateSelector checks the syntax of value if by is ByCSSSelector or
+// ByXPATH.
+func validateSelector(by, value string) error {
+	switch by {
+	case ByCSSSelector:
+		return ValidateCSS(value)
+	case ByXPATH:
+		return ValidateXPath(value)
+	}
+	return nil
+}
+
+// found describes what is at offset i of s, for an error message.
+func found(s string, i int, end string) string {
+	if i >= len(s) {
+		return end
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return fmt.Sprintf("%q", r)
+}
+
+// ValidateCSS checks the syntax of a CSS selector list, returning a
+// *SelectorError if it is invalid. The arguments of functional
+// pseudo-classes other than :not and :has are only checked for balanced
+// parentheses.
+func ValidateCSS(sel string) error {
+	p := &cssParser{s: sel}
+	if strings.TrimSpace(sel) == "" {
+		return p.errorf(0, "empty selector")
+	}
+	if err := p.selectorList(false); err != nil {
+		return err
+	}
+	if p.pos < len(p.s) {
+		return p.errorf(p.pos, "unexpected %s", found(p.s, p.pos, ""))
+	}
+	return nil
+}
+
+type cssParser struct {
+	s   string
+	pos int
+}
+
+func (p *cssParser) errorf(pos int, format string, args ...interface{}) error {
+	return &SelectorError{By: ByCSSSelector, Selector: p.s, Offset: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *cssParser) expected(what string) error {
+	return p.errorf(p.pos, "expected %s, found %s", what, found(p.s, p.pos, "end of selector"))
+}
+
+// at returns the byte at offset i, or 0 past the end.
+func (p *cssParser) at(i int) byte {
+	if i < len(p.s) {
+		return p.s[i]
+	}
+	return 0
+}
+
+func (p *cssParser) peek() byte {
+	return p.at(p.pos)
+}
+
+func (p *cssParser) consume(c byte) bool {
+	if p.peek() == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments, reporting whether there were
+// any.
+func (p *cssParser) skipSpace() bool {
+	start := p.pos
+	for {
+		switch {
+		case strings.IndexByte(" \t\n\r\f", p.peek()) >= 0:
+			p.pos++
+		case strings.HasPrefix(p.s[p.pos:], "/*"):
+			end := strings.Index(p.s[p.pos+2:], "*/")
+			if end < 0 {
+				p.pos = len(p.s)
+			} else {
+				p.pos += end + 4
+			}
+		default:
+			return p.pos > start
+		}
+	}
+}
+
+// selectorList parses comma-separated complex selectors, which start with
+// a combinator if relative.
+func (p *cssParser) selectorList(relative bool) error {
+	for {
+		p.skipSpace()
+		if err := p.complex(relative); err != nil {
+			return err
+		}
+		if !p.consume(',') {
+			return nil
+		}
+	}
+}
+
+// complex parses compound selectors separated by combinators.
+func (p *cssParser) complex(relative bool) error {
+	if c := p.peek(); relative && (c == '>' || c == '+' || c == '~') {
+		p.pos++
+		p.skipSpace()
+	}
+	for {
+		if err := p.compound(); err != nil {
+			return err
+		}
+		space := p.skipSpace()
+		switch p.peek() {
+		case '>', '+', '~':
+			p.pos++
+			p.skipSpace()
+			continue
+		case ',', ')', 0:
+			return nil
+		}
+		if !space {
+			return p.errorf(p.pos, "unexpected %s", found(p.s, p.pos, ""))
+		}
+	}
+}
+
+// compound parses a type or universal selector followed by ID, class,
+// attribute and pseudo-class selectors.
+func (p *cssParser) compound() error {
+	start := p.pos
+	if c := p.peek(); c == '*' || c == '|' || p.startsIdent() {
+		if err := p.typeSelector(); err != nil {
+			return err
+		}
+	}
+	for {
+		var err error
+		switch p.peek() {
+		case '#':
+			p.pos++
+			if !p.startsName() {
+				return p.expected("ID")
+			}
+			err = p.name()
+		case '.':
+			p.pos++
+			err = p.ident("class name")
+		case '[':
+			err = p.attribute()
+		case ':':
+			err = p.pseudo()
+		default:
+			if p.pos == start {
+				return p.expected("selector")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *cssParser) typeSelector() error {
+	if !p.consume('*') && p.peek() != '|' {
+		if err := p.ident("element name"); err != nil {
+			return err
+		}
+	}
+	if p.consume('|') {
+		if p.consume('*') {
+			return nil
+		}
+		return p.ident("element name")
+	}
+	return nil
+}
+
+func (p *cssParser) attribute() error {
+	open := p.pos
+	p.pos++
+	p.skipSpace()
+	if p.peek() == '*' && p.at(p.pos+1) == '|' {
+		p.pos += 2
+	} else if p.peek() == '|' && p.at(p.pos+1) != '=' {
+		p.pos++
+	}
+	if err := p.ident("attribute name"); err != nil {
+		return err
+	}
+	if p.peek() == '|' && p.at(p.pos+1) != '=' {
+		p.pos++
+		if err := p.ident("attribute name"); err != nil {
+			return err
+		}
+	}
+	p.skipSpace()
+	if p.consume(']') {
+		return nil
+	}
+
+	switch {
+	case p.peek() == '=':
+		p.pos++
+	case strings.IndexByte("~|^$*", p.peek()) >= 0 && p.at(p.pos+1) == '=':
+		p.pos += 2
+	case p.pos == len(p.s):
+		return p.errorf(open, "unclosed [")
+	default:
+		return p.expected("] or attribute operator")
+	}
+	p.skipSpace()
+	if c := p.peek(); c == '"' || c == '\'' {
+		if err := p.str(); err != nil {
+			return err
+		}
+	} else if err := p.ident("attribute value"); err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.startsIdent() {
+		mod := p.pos
+		p.name()
+		if m := strings.ToLower(p.s[mod:p.pos]); m != "i" && m != "s" {
+			return p.errorf(mod, "unknown attribute modifier %q", p.s[mod:p.pos])
+		}
+		p.skipSpace()
+	}
+	if !p.consume(']') {
+		if p.pos == len(p.s) {
+			return p.errorf(open, "unclosed [")
+		}
+		return p.expected("]")
+	}
+	return nil
+}
+
+func (p *cssParser) pseudo() error {
+	p.pos++
+	element := p.consume(':')
+	start := p.pos
+	if err := p.ident("pseudo-class name"); err != nil {
+		return err
+	}
+	name := strings.ToLower(p.s[start:p.pos])
+	open := p.pos
+	if !p.consume('(') {
+		return nil
+	}
+	if !element && (name == "not" || name == "has") {
+		if err := p.selectorList(name == "has"); err != nil {
+			return err
+		}
+		p.skipSpace()
+	} else if err := p.balanced(); err != nil {
+		return err
+	}
+	if !p.consume(')') {
+		if p.pos == len(p.s) {
+			return p.errorf(open, "unclosed (")
+		}
+		return p.expected(")")
+	}
+	return nil
+}
+
+// balanced skips to the ) closing an argument list.
+func (p *cssParser) balanced() error {
+	var open []int
+	for p.pos < len(p.s) {
+		switch c := p.peek(); c {
+		case '(', '[':
+			open = append(open, p.pos)
+		case ')', ']':
+			if len(open) == 0 && c == ')' {
+				return nil
+			}
+			want := byte('(')
+			if c == ']' {
+				want = '['
+			}
+			if len(open) == 0 || p.s[open[len(open)-1]] != want {
+				return p.errorf(p.pos, "unexpected %q", c)
+			}
+			open = open[:len(open)-1]
+		case '"', '\'':
+			if err := p.str(); err != nil {
+				return err
+			}
+			continue
+		case '\\':
+			if err := p.escape(); err != nil {
+				return err
+			}
+			continue
+		}
+		p.pos++
+	}
+	if len(open) > 0 {
+		return p.errorf(open[len(open)-1], "unclosed %c", p.s[open[len(open)-1]])
+	}
+	return nil
+}
+
+func isCSSNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isCSSNameChar(c byte) bool {
+	return isCSSNameStart(c) || c >= '0' && c <= '9' || c == '-'
+}
+
+// startsIdent reports whether an identifier starts at the current offset.
+func (p *cssParser) startsIdent() bool {
+	i := p.pos
+	if p.at(i) == '-' {
+		if i++; p.at(i) == '-' {
+			return true
+		}
+	}
+	return isCSSNameStart(p.at(i)) || p.at(i) == '\\' && i+1 < len(p.s) && p.at(i+1) != '\n'
+}
+
+// startsName reports whether a name, which unlike an identifier may start
+// with a digit, starts at the current offset.
+func (p *cssParser) startsName() bool {
+	return isCSSNameChar(p.peek()) || p.peek() == '\\'
+}
+
+// ident parses an identifier, the what of the selector.
+func (p *cssParser) ident(what string) error {
+	if !p.startsIdent() {
+		return p.expected(what)
+	}
+	return p.name()
+}
+
+func (p *cssParser) name() error {
+	for {
+		switch c := p.peek(); {
+		case isCSSNameChar(c):
+			p.pos++
+		case c == '\\':
+			if err := p.escape(); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
+
+func (p *cssParser) escape() error {
+	start := p.pos
+	p.pos++
+	switch c := p.peek(); {
+	case p.pos == len(p.s) || c == '\n':
+		return p.errorf(start, "incomplete escape")
+	case strings.IndexByte("0123456789abcdefABCDEF", c) >= 0:
+		for n := 0; n < 6 && strings.IndexByte("0123456789abcdefABCDEF", p.peek()) >= 0; n++ {
+			p.pos++
+		}
+		if c := p.peek(); c == ' ' || c == '\t' || c == '\n' {
+			p.pos++
+		}
+	default:
+		_, size := utf8.DecodeRuneInString(p.s[p.pos:])
+		p.pos += size
+	}
+	return nil
+}
+
+func (p *cssParser) str() error {
+	start := p.pos
+	quote := p.peek()
+	p.pos++
+	for p.pos < len(p.s) {
+		switch p.peek() {
+		case quote:
+			p.pos++
+			return nil
+		case '\\':
+			if p.pos++; p.pos < len(p.s) {
+				p.pos++
+			}
+		case '\n':
+			return p.errorf(start, "unterminated string")
+		default:
+			p.pos++
+		}
+	}
+	return p.errorf(start, "unterminated string")
+}
@@ -0,0 +1,147 @@
+package selenium
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidateCSS(t *testing.T) {
+	for _, sel := range []string{
+		`div`,
+		`ul.list > li:nth-child(2n+1) a[href^="https://"]`,
+		`#main, .a.b ~ p + span`,
+		`input[type=checkbox]:not(:checked, [disabled])`,
+		`article:has(> img, h2)`,
+		`*|svg svg|rect [xlink|href] [ data-x = 'a\'b' i ]`,
+		`#\31 23 .-foo .--bar ._x a::before`,
+		`li:is(.a, :where(.b))`,
+		`div /* comment */ p`,
+		"héllo",
+	} {
+		if err := ValidateCSS(sel); err != nil {
+			t.Errorf("ValidateCSS(%q) = %v", sel, err)
+		}
+	}
+
+	for _, test := range []struct {
+		sel    string
+		offset int
+		msg    string
+	}{
+		{``, 0, "empty selector"},
+		{`div > > p`, 6, "expected selector, found '>'"},
+		{`a,`, 2, "expected selector, found end of selector"},
+		{`.1a`, 1, "expected class name, found '1'"},
+		{`a[href`, 1, "unclosed ["},
+		{`a[x=1]`, 4, "expected attribute value, found '1'"},
+		{`a[x="y]`, 4, "unterminated string"},
+		{`a[x=y z]`, 6, `unknown attribute modifier "z"`},
+		{`a:not(.b`, 5, "unclosed ("},
+		{`li:nth-child(2n+1`, 12, "unclosed ("},
+		{`a)`, 1, "unexpected ')'"},
+		{`a$b`, 1, "unexpected '$'"},
+	} {
+		err := ValidateCSS(test.sel)
+		serr, ok := err.(*SelectorError)
+		if !ok {
+			t.Errorf("ValidateCSS(%q) = %v, want a *SelectorError", test.sel, err)
+			continue
+		}
+		if serr.Offset != test.offset || serr.Msg != test.msg {
+			t.Errorf("ValidateCSS(%q): got %q at offset %d, want %q at offset %d", test.sel, serr.Msg, serr.Offset, test.msg, test.offset)
+		}
+	}
+}
+
+func TestValidateXPath(t *testing.T) {
+	for _, expr := range []string{
+		`//div`,
+		`/html/body//a[@href and not(@rel="nofollow")][last()]`,
+		`.//li[2]/following-sibling::*[1] | ../span`,
+		`//*[contains(normalize-space(.), "Sign in") and not(*[contains(normalize-space(.), "Sign in")])]`,
+		`count(//tr) * 2 div 4 mod 3 - -1 >= 0`,
+		`//svg:rect/@*`,
+		`(//a)[position() < 3]/text()`,
+		`//comment() | //processing-instruction('x') | //node()`,
+		`$items[. != 'x']`,
+		`/`,
+		`//*[@id=//label[normalize-space(.)="Email"]/@for]`,
+		`concat("it's ", '"', "quoted")`,
+		"//div\n\t[@class = 'a']",
+	} {
+		if err := ValidateXPath(expr); err != nil {
+			t.Errorf("ValidateXPath(%q) = %v", expr, err)
+		}
+	}
+
+	for _, test := range []struct {
+		expr   string
+		offset int
+		msg    string
+	}{
+		{` `, 0, "empty expression"},
+		{`//div[@class="a"`, 16, `expected "]", found end of expression`},
+		{`//div[`, 6, "expected location step, found end of expression"},
+		{`//a[@x='y]`, 7, "unterminated string literal"},
+		{`//a b`, 4, `expected operator, found "b"`},
+		{`//a)`, 3, `unexpected ")"`},
+		{`childs::a`, 0, `unknown axis "childs"`},
+		{`//a[@x ! 'y']`, 7, "expected '=' after '!'"},
+		{`//`, 2, "expected location step, found end of expression"},
+		{`//a:`, 4, "expected name or '*' after ':', found end of expression"},
+		{`count(//a,`, 10, "expected location step, found end of expression"},
+		{`//a#b`, 3, "unexpected '#'"},
+	} {
+		err := ValidateXPath(test.expr)
+		serr, ok := err.(*SelectorError)
+		if !ok {
+			t.Errorf("ValidateXPath(%q) = %v, want a *SelectorError", test.expr, err)
+			continue
+		}
+		if serr.Offset != test.offset || serr.Msg != test.msg {
+			t.Errorf("ValidateXPath(%q): got %q at offset %d, want %q at offset %d", test.expr, serr.Msg, serr.Offset, test.msg, test.offset)
+		}
+	}
+}
+
+func TestSelectorError(t *testing.T) {
+	err := ValidateCSS("é > > p")
+	want := "invalid css selector: expected selector, found '>' at offset 5\n\té > > p\n\t    ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+}
+
+func TestFind_SelectorValidation(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var sent []string
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.URL.Path)
+		fmt.Fprint(w, `{"status": 0, "value": {"ELEMENT": "a"}}`)
+	})
+
+	if _, err := client.FindElement(ByXPATH, "//a["); err == nil || !strings.Contains(err.Error(), "invalid xpath") {
+		t.Errorf("got error %v, want an invalid xpath error", err)
+	}
+	if _, err := client.Q("a > > b"); err == nil {
+		t.Error("got no error for an invalid CSS selector")
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent %d requests for invalid selectors", len(sent))
+	}
+
+	wd, err := NewRemote(caps, server.URL, WithoutSelectorValidation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wd.FindElement(ByXPATH, "//a["); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("sent %d requests without selector validation, want 1", len(sent))
+	}
+}
@@ -135,6 +135,9 @@ type WebDriver interface {
 	/* Start a new session, return session id */
 	NewSession() (string, error)
 
+	/* ID of the current session */
+	SessionID() string
+
 	/* Current session capabilities */
 	Capabilities() (Capabilities, error)
 
@@ -235,12 +238,54 @@ type WebDriver interface {
 	/* Mouse button up */
 	ButtonUp() error
 
+	// Touch
+	/* Touch gestures, for mobile browsers. */
+	TouchScreen() TouchScreen
+
 	// Misc
 	/* Send modifier key to active element.
 	modifier can be one of ShiftKey, ControlKey, AltKey, MetaKey.
 	*/
 	SendModifier(modifier string, isDown bool) error
 	Screenshot() (io.Reader, error)
+	/* Print the current page, return the PDF document (W3C only). */
+	PrintPage(opts *PrintOptions) (io.Reader, error)
+	/* Copy the local file at path to the remote end, return its path there, to be sent with SendKeys to an <input type=file>. */
+	UploadFile(path string) (string, error)
+
+	// Emulation
+	/* Current geographic position of the browser. */
+	Geolocation() (*Location, error)
+	/* Set the geographic position reported to the page. */
+	SetGeolocation(loc Location) error
+	/* Current screen orientation (mobile only). */
+	Orientation() (ScreenOrientation, error)
+	/* Set the screen orientation (mobile only). */
+	SetOrientation(o ScreenOrientation) error
+	/* Enabled network connections (mobile only). */
+	NetworkConnection() (NetworkConnection, error)
+	/* Set the enabled network connections, return the resulting ones (mobile only). */
+	SetNetworkConnection(c NetworkConnection) (NetworkConnection, error)
+
+	// Web storage
+	/* The page's localStorage. */
+	LocalStorage() Storage
+	/* The page's sessionStorage. */
+	SessionStorage() Storage
+
+	// Logs
+	/* Available log types, e.g. BrowserLog. */
+	LogTypes() ([]string, error)
+	/* Get the entries of a log collected since the last call. */
+	Log(logType string) ([]LogEntry, error)
+
+	// Downloads (Selenium 4 Grid, see Capabilities.EnableDownloads)
+	/* Names of the files downloaded by the browser. */
+	DownloadedFiles() ([]string, error)
+	/* Contents of a downloaded file. */
+	DownloadedFile(name string) ([]byte, error)
+	/* Delete all downloaded files. */
+	DeleteDownloadedFiles() error
 
 	// Alerts
 	/* Dismiss current alert. */
@@ -257,6 +302,8 @@ type WebDriver interface {
 	ExecuteScript(script string, args []interface{}) (interface{}, error)
 	/* Execute a script async. */
 	ExecuteScriptAsync(script string, args []interface{}) (interface{}, error)
+	/* Execute a Chrome DevTools Protocol command (Chrome and Edge only), see DevTools. */
+	ExecuteCDP(method string, params map[string]interface{}) (map[string]interface{}, error)
 
 	// Get a WebDriverT of this element that has methods that call t.Fatalf upon
 	// encountering errors instead of using multiple returns to indicate errors.
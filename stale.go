@@ -0,0 +1,106 @@
+package selenium
+
+import "fmt"
+
+// WithStaleElementRecovery makes the elements found by the WebDriver
+// remember how they were found: by their parent element, locator and
+// index in the result of FindElements. A command failing with a "stale
+// element reference" error, e.g. because the page re-rendered the element,
+// is then sent again once to the element found again the same way.
+func WithStaleElementRecovery() RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.staleRecovery = true
+	}
+}
+
+// A locator says how an element was found.
+type locator struct {
+	// parent is the element the element was found in, or nil.
+	parent    *remoteWE
+	by, value string
+	// index is the index of the element in the result of FindElements, or
+	// -1 if it was found by FindElement.
+	index int
+}
+
+// located records how elems were found, if the session recovers stale
+// elements.
+func (wd *remoteWebDriver) located(elems []WebElement, parent *remoteWE, by, value string, many bool) {
+	if !wd.staleRecovery {
+		return
+	}
+	for i, e := range elems {
+		l := &locator{parent: parent, by: by, value: value, index: -1}
+		if many {
+			l.index = i
+		}
+		e.(*remoteWE).loc = l
+	}
+}
+
+func isStale(err error) bool {
+	e, ok := err.(*serverError)
+	return ok && e.message == "stale element reference"
+}
+
+// retry calls fn, and again after finding the element anew if fn failed
+// because the element is stale.
+func (elem *remoteWE) retry(fn func() error) error {
+	err := fn()
+	if err == nil || elem.loc == nil || !isStale(err) {
+		return err
+	}
+	if err := elem.relocate(); err != nil {
+		if Log != nil {
+			Log.Printf("can't find stale element %s again: %s", elem.currentID(), err)
+		}
+		return err
+	}
+	return fn()
+}
+
+// relocate finds the element again as it was found first, and updates its
+// ID, in a span, see TraceSpan. A stale parent is found again in turn.
+func (elem *remoteWE) relocate() error {
+	return TraceSpan(elem.parent, "relocate stale element", elem.findAgain)
+}
+
+func (elem *remoteWE) findAgain() error {
+	l := elem.loc
+	var found *remoteWE
+	if l.index < 0 {
+		var e WebElement
+		var err error
+		if l.parent != nil {
+			e, err = l.parent.FindElement(l.by, l.value)
+		} else {
+			e, err = elem.parent.FindElement(l.by, l.value)
+		}
+		if err != nil {
+			return err
+		}
+		found = e.(*remoteWE)
+	} else {
+		var elems []WebElement
+		var err error
+		if l.parent != nil {
+			elems, err = l.parent.FindElements(l.by, l.value)
+		} else {
+			elems, err = elem.parent.FindElements(l.by, l.value)
+		}
+		if err != nil {
+			return err
+		}
+		if l.index >= len(elems) {
+			return fmt.Errorf("found %d elements by %s %q, no element %d", len(elems), l.by, l.value, l.index)
+		}
+		found = elems[l.index].(*remoteWE)
+	}
+	elem.idMu.Lock()
+	if Log != nil {
+		Log.Printf("found stale element %s again as %s", elem.id, found.currentID())
+	}
+	elem.id = found.currentID()
+	elem.idMu.Unlock()
+	return nil
+}
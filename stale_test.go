@@ -0,0 +1,116 @@
+package selenium
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// handleRerenderedList serves a list whose items are re-rendered, getting
+// new element IDs, whenever *render changes.
+func handleRerenderedList(render *int) {
+	stale := func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "stale element reference", "message": ""}}`)
+	}
+	mux.HandleFunc("/session/123/element", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status": 0, "value": {"ELEMENT": "list%d"}}`, *render)
+	})
+	mux.HandleFunc("/session/123/element/", func(w http.ResponseWriter, r *http.Request) {
+		// /session/123/element/{id}/{command}
+		parts := strings.Split(r.URL.Path, "/")
+		id, command := parts[4], parts[5]
+		if !strings.HasSuffix(id, fmt.Sprint(*render)) {
+			stale(w)
+			return
+		}
+		switch command {
+		case "elements":
+			fmt.Fprintf(w, `{"status": 0, "value": [{"ELEMENT": "a%d"}, {"ELEMENT": "b%d"}]}`, *render, *render)
+		case "text":
+			fmt.Fprintf(w, `{"status": 0, "value": "%s"}`, id)
+		}
+	})
+}
+
+func TestWithStaleElementRecovery(t *testing.T) {
+	setup()
+	defer teardown()
+
+	render := 1
+	handleRerenderedList(&render)
+
+	wd, err := NewRemote(caps, server.URL, WithStaleElementRecovery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := wd.Q("ul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := list.QAll("li")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the item and its parent list are stale.
+	render = 2
+	text, err := items[1].Text()
+	if err != nil || text != "b2" {
+		t.Errorf("Text of a stale element returned %q, %v, want b2", text, err)
+	}
+	if id, _ := elementID(list); id != "list2" {
+		t.Errorf("stale parent has ID %q after recovery, want list2", id)
+	}
+}
+
+func TestWithStaleElementRecovery_FindElement(t *testing.T) {
+	setup()
+	defer teardown()
+
+	render := 1
+	handleRerenderedList(&render)
+
+	wd, err := NewRemote(caps, server.URL, WithStaleElementRecovery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := wd.Q("ul")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The element may be used from several goroutines.
+	render = 2
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			text, err := list.Text()
+			if err != nil || text != "list2" {
+				t.Errorf("Text of a stale element returned %q, %v, want list2", text, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWithStaleElementRecovery_Disabled(t *testing.T) {
+	setup()
+	defer teardown()
+
+	render := 1
+	handleRerenderedList(&render)
+
+	list, err := client.Q("ul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	render = 2
+	if _, err := list.Text(); !isStale(err) {
+		t.Errorf("Text of a stale element returned %v, want stale element reference", err)
+	}
+}
@@ -0,0 +1,140 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// Storage is one of the browser's web storage areas for the current page:
+// localStorage or sessionStorage.
+type Storage interface {
+	/* Keys of all stored items. */
+	Keys() ([]string, error)
+	/* Value of an item, "" if there is no such item. */
+	Get(key string) (string, error)
+	/* Set the value of an item. */
+	Set(key, value string) error
+	/* Remove an item. */
+	Remove(key string) error
+	/* Remove all items. */
+	Clear() error
+	/* Number of stored items. */
+	Size() (int, error)
+}
+
+// webStorage implements Storage with the JSON Wire storage endpoints, and
+// falls back to scripts on remote ends that don't implement them.
+type webStorage struct {
+	wd *remoteWebDriver
+	// endpoint is the name of the JSON Wire endpoint, e.g. "local_storage".
+	endpoint string
+	// object is the name of the storage object in the page, e.g. "localStorage".
+	object string
+}
+
+func (wd *remoteWebDriver) LocalStorage() Storage {
+	return &webStorage{wd: wd, endpoint: "local_storage", object: "localStorage"}
+}
+
+func (wd *remoteWebDriver) SessionStorage() Storage {
+	return &webStorage{wd: wd, endpoint: "session_storage", object: "sessionStorage"}
+}
+
+// command sends a storage command to the remote end and decodes its value
+// into v (if not nil). It returns false once the remote end has been found
+// not to implement the storage endpoints.
+func (s *webStorage) command(method, path string, params, v interface{}) (ok bool, err error) {
+	if s.wd.scriptStorage {
+		return false, nil
+	}
+	var data []byte
+	if params != nil {
+		if data, err = json.Marshal(params); err != nil {
+			return true, err
+		}
+	}
+	r, err := s.wd.send(method, s.wd.url("/session/%s/%s%s", s.wd.id, s.endpoint, path), data)
+	if isUnknownCommand(err) {
+		s.wd.scriptStorage = true
+		return false, nil
+	}
+	if err == nil && v != nil && r != nil {
+		err = r.readValue(v)
+	}
+	return true, err
+}
+
+// script runs a script with the storage object as its first argument,
+// followed by args.
+func (s *webStorage) script(script string, args ...interface{}) (interface{}, error) {
+	script = "var storage = window[arguments[0]];\n" + script
+	return s.wd.ExecuteScript(script, append([]interface{}{s.object}, args...))
+}
+
+func (s *webStorage) Keys() (keys []string, err error) {
+	if ok, err := s.command("GET", "", nil, &keys); ok {
+		return keys, err
+	}
+	res, err := s.script(`var keys = [];
+for (var i = 0; i < storage.length; i++) {
+	keys.push(storage.key(i));
+}
+return keys;`)
+	if err != nil {
+		return nil, err
+	}
+	values, _ := res.([]interface{})
+	keys = make([]string, len(values))
+	for i, v := range values {
+		keys[i] = fmt.Sprint(v)
+	}
+	return keys, nil
+}
+
+func (s *webStorage) Get(key string) (value string, err error) {
+	if ok, err := s.command("GET", "/key/"+url.PathEscape(key), nil, &value); ok {
+		return value, err
+	}
+	res, err := s.script("return storage.getItem(arguments[1]);", key)
+	if err != nil || res == nil {
+		return "", err
+	}
+	return fmt.Sprint(res), nil
+}
+
+func (s *webStorage) Set(key, value string) error {
+	if ok, err := s.command("POST", "", map[string]string{"key": key, "value": value}, nil); ok {
+		return err
+	}
+	_, err := s.script("storage.setItem(arguments[1], arguments[2]);", key, value)
+	return err
+}
+
+func (s *webStorage) Remove(key string) error {
+	if ok, err := s.command("DELETE", "/key/"+url.PathEscape(key), nil, nil); ok {
+		return err
+	}
+	_, err := s.script("storage.removeItem(arguments[1]);", key)
+	return err
+}
+
+func (s *webStorage) Clear() error {
+	if ok, err := s.command("DELETE", "", nil, nil); ok {
+		return err
+	}
+	_, err := s.script("storage.clear();")
+	return err
+}
+
+func (s *webStorage) Size() (n int, err error) {
+	if ok, err := s.command("GET", "/size", nil, &n); ok {
+		return n, err
+	}
+	res, err := s.script("return storage.length;")
+	if err != nil {
+		return 0, err
+	}
+	size, _ := res.(float64)
+	return int(size), nil
+}
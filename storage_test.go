@@ -0,0 +1,103 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	setup()
+	defer teardown()
+
+	items := map[string]string{"token": "abc"}
+	mux.HandleFunc("/session/123/local_storage", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `{"status": 0, "value": ["token"]}`)
+		case "POST":
+			var v map[string]string
+			json.NewDecoder(r.Body).Decode(&v)
+			items[v["key"]] = v["value"]
+			fmt.Fprint(w, `{"status": 0}`)
+		case "DELETE":
+			items = map[string]string{}
+			fmt.Fprint(w, `{"status": 0}`)
+		}
+	})
+	mux.HandleFunc("/session/123/local_storage/key/a b", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprintf(w, `{"status": 0, "value": %q}`, items["a b"])
+	})
+	mux.HandleFunc("/session/123/local_storage/size", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status": 0, "value": %d}`, len(items))
+	})
+
+	s := client.LocalStorage()
+	keys, err := s.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned error: %v", err)
+	}
+	if want := []string{"token"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys returned %q, want %q", keys, want)
+	}
+	if err := s.Set("a b", "1"); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if v, err := s.Get("a b"); err != nil || v != "1" {
+		t.Errorf("Get returned %q, %v; want %q", v, err, "1")
+	}
+	if n, err := s.Size(); err != nil || n != 2 {
+		t.Errorf("Size returned %d, %v; want 2", n, err)
+	}
+	if err := s.Clear(); err != nil {
+		t.Fatalf("Clear returned error: %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("Clear left items %+v", items)
+	}
+}
+
+func TestSessionStorage_ScriptFallback(t *testing.T) {
+	setup()
+	defer teardown()
+
+	endpointCalls := 0
+	mux.HandleFunc("/session/123/session_storage/size", func(w http.ResponseWriter, r *http.Request) {
+		endpointCalls++
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"value": {"error": "unknown command", "message": "not supported"}}`)
+	})
+	var scripts []string
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Script string
+			Args   []interface{}
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+		scripts = append(scripts, v.Script)
+		if v.Args[0] != "sessionStorage" {
+			t.Errorf("storage object = %v, want sessionStorage", v.Args[0])
+		}
+		fmt.Fprint(w, `{"status": 0, "value": 3}`)
+	})
+
+	s := client.SessionStorage()
+	for i := 0; i < 2; i++ {
+		n, err := s.Size()
+		if err != nil {
+			t.Fatalf("Size returned error: %v", err)
+		}
+		if n != 3 {
+			t.Errorf("Size returned %d, want 3", n)
+		}
+	}
+	if endpointCalls != 1 {
+		t.Errorf("storage endpoint called %d times, want 1", endpointCalls)
+	}
+	if len(scripts) != 2 {
+		t.Errorf("ran %d scripts, want 2", len(scripts))
+	}
+}
@@ -0,0 +1,201 @@
+package selenium
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// A Table holds the text contents of an HTML table element.
+type Table struct {
+	// Headers holds the text of the header cells, one per column.
+	Headers []string
+	// Rows holds the text of the body cells, one slice per row.
+	Rows [][]string
+	// Footer holds the text of the <tfoot> cells, e.g. totals, one slice
+	// per row. Unmarshal ignores them.
+	Footer [][]string
+}
+
+// tableScript lays out the cells of the table passed as arguments[0] on a
+// grid, repeating cells that span several rows or columns. Header cells are
+// taken from the last row of the <thead>, or from the first row when it
+// contains only <th> cells and the table has no <thead>. The rows of the
+// <tfoot>, which come last in table.rows, are returned apart.
+const tableScript = `
+var table = arguments[0];
+var grid = [], headerRows = 0, footerStart = table.rows.length;
+for (var r = table.rows.length - 1; r >= 0 && table.rows[r].parentNode.tagName === "TFOOT"; r--) {
+	footerStart = r;
+}
+for (var r = 0; r < table.rows.length; r++) {
+	var row = table.rows[r];
+	if (row.parentNode.tagName === "THEAD") {
+		headerRows = r + 1;
+	}
+	grid[r] = grid[r] || [];
+	var c = 0;
+	for (var i = 0; i < row.cells.length; i++) {
+		var cell = row.cells[i];
+		while (grid[r][c] !== undefined) {
+			c++;
+		}
+		var text = (cell.innerText || cell.textContent || "").replace(/\s+/g, " ").trim();
+		var rowspan = Math.max(cell.rowSpan || 1, 1), colspan = Math.max(cell.colSpan || 1, 1);
+		var end = r < footerStart ? footerStart : table.rows.length;
+		for (var y = 0; y < rowspan && r + y < end; y++) {
+			grid[r + y] = grid[r + y] || [];
+			for (var x = 0; x < colspan; x++) {
+				grid[r + y][c + x] = text;
+			}
+		}
+		c += colspan;
+	}
+}
+if (!headerRows && table.rows.length > 0) {
+	var first = table.rows[0], allTH = first.cells.length > 0;
+	for (var i = 0; i < first.cells.length; i++) {
+		allTH = allTH && first.cells[i].tagName === "TH";
+	}
+	if (allTH) {
+		headerRows = 1;
+	}
+}
+for (var r = 0; r < grid.length; r++) {
+	for (var c = 0; c < grid[r].length; c++) {
+		if (grid[r][c] === undefined) {
+			grid[r][c] = "";
+		}
+	}
+}
+return {
+	headers: headerRows ? grid[headerRows - 1] : [],
+	rows: grid.slice(headerRows, footerStart),
+	footer: grid.slice(footerStart)
+};
+`
+
+// ReadTable reads the headers and rows of the <table> element elem in a
+// single script round trip instead of querying each cell. Cells that span
+// several rows or columns (rowspan/colspan) are repeated in every position
+// they cover, so each row has one entry per column.
+func ReadTable(wd WebDriver, elem WebElement) (*Table, error) {
+	res, err := wd.ExecuteScript(tableScript, []interface{}{elem})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var v struct {
+		Headers []string   `json:"headers"`
+		Rows    [][]string `json:"rows"`
+		Footer  [][]string `json:"footer"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("reading table: %s", err)
+	}
+	return &Table{Headers: v.Headers, Rows: v.Rows, Footer: v.Footer}, nil
+}
+
+// Unmarshal stores the rows of the table in the slice pointed to by v, whose
+// elements must be structs or pointers to structs. Columns are matched to
+// fields by header text, using the field's `table` tag if present and its
+// name otherwise, ignoring case. A tag of "-" skips the field. String, bool,
+// integer and floating point fields are supported; empty cells leave the
+// field at its zero value.
+func (t *Table) Unmarshal(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("Unmarshal: v must be a pointer to a slice")
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("Unmarshal: unsupported slice element type %s", elemType)
+	}
+
+	// columns[i] is the index of the field filled from column i, or -1.
+	columns := make([]int, len(t.Headers))
+	for i, header := range t.Headers {
+		columns[i] = -1
+		for j := 0; j < structType.NumField(); j++ {
+			f := structType.Field(j)
+			if f.PkgPath != "" {
+				continue
+			}
+			name := f.Tag.Get("table")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if strings.EqualFold(name, header) {
+				columns[i] = j
+				break
+			}
+		}
+	}
+
+	rows := reflect.MakeSlice(slice.Type(), 0, len(t.Rows))
+	for r, row := range t.Rows {
+		sv := reflect.New(structType).Elem()
+		for i, cell := range row {
+			if i >= len(columns) || columns[i] < 0 || cell == "" {
+				continue
+			}
+			if err := setTableField(sv.Field(columns[i]), cell); err != nil {
+				return fmt.Errorf("Unmarshal: row %d, column %q: %s", r, t.Headers[i], err)
+			}
+		}
+		if elemType.Kind() == reflect.Ptr {
+			sv = sv.Addr()
+		}
+		rows = reflect.Append(rows, sv)
+	}
+	slice.Set(rows)
+	return nil
+}
+
+func setTableField(f reflect.Value, s string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestReadTable(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		var v struct {
+			Args []map[string]string
+		}
+		json.NewDecoder(r.Body).Decode(&v)
+
+		want := []map[string]string{{"ELEMENT": "t1"}}
+		if !reflect.DeepEqual(v.Args, want) {
+			t.Errorf("Args = %+v, want %+v", v.Args, want)
+		}
+
+		fmt.Fprint(w, `{"status": 0, "value": {"headers": ["Name", "Age"], "rows": [["alice", "30"], ["bob", ""]], "footer": [["Total", "30"]]}}`)
+	})
+
+	table, err := ReadTable(client, &remoteWE{parent: client.(*remoteWebDriver), id: "t1"})
+	if err != nil {
+		t.Fatalf("ReadTable returned error: %v", err)
+	}
+
+	want := &Table{
+		Headers: []string{"Name", "Age"},
+		Rows:    [][]string{{"alice", "30"}, {"bob", ""}},
+		Footer:  [][]string{{"Total", "30"}},
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("ReadTable returned %+v, want %+v", table, want)
+	}
+
+	var people []struct{ Name string }
+	if err := table.Unmarshal(&people); err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 2 {
+		t.Errorf("Unmarshal got %+v, want the body rows only", people)
+	}
+}
+
+func TestTable_Unmarshal(t *testing.T) {
+	table := &Table{
+		Headers: []string{"Name", "Age", "E-mail", "Notes"},
+		Rows: [][]string{
+			{"alice", "30", "alice@example.com", "x"},
+			{"bob", "", "bob@example.com", "y"},
+		},
+	}
+
+	type person struct {
+		Name  string
+		Age   int
+		Email string `table:"e-mail"`
+		Notes string `table:"-"`
+	}
+
+	var people []person
+	if err := table.Unmarshal(&people); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	want := []person{
+		{Name: "alice", Age: 30, Email: "alice@example.com"},
+		{Name: "bob", Email: "bob@example.com"},
+	}
+	if !reflect.DeepEqual(people, want) {
+		t.Errorf("Unmarshal got %+v, want %+v", people, want)
+	}
+
+	var ptrs []*person
+	if err := table.Unmarshal(&ptrs); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if len(ptrs) != 2 || *ptrs[0] != want[0] {
+		t.Errorf("Unmarshal into pointers got %+v, want %+v", ptrs, want)
+	}
+
+	table.Rows[0][1] = "thirty"
+	if err := table.Unmarshal(&people); err == nil {
+		t.Error("Unmarshal of non-numeric age: expected error")
+	}
+}
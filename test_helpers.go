@@ -64,6 +64,11 @@ type WebDriverT interface {
 
 	SendModifier(modifier string, isDown bool)
 	Screenshot() io.Reader
+	PrintPage(opts *PrintOptions) io.Reader
+	UploadFile(path string) string
+
+	LogTypes() []string
+	Log(logType string) []LogEntry
 
 	DismissAlert()
 	AcceptAlert()
@@ -332,6 +337,38 @@ func (wt *webDriverT) Screenshot() (data io.Reader) {
 	return
 }
 
+func (wt *webDriverT) PrintPage(opts *PrintOptions) (pdf io.Reader) {
+	var err error
+	if pdf, err = wt.d.PrintPage(opts); err != nil {
+		fatalf(wt.t, "PrintPage(%+v): %s", opts, err)
+	}
+	return
+}
+
+func (wt *webDriverT) UploadFile(path string) (remotePath string) {
+	var err error
+	if remotePath, err = wt.d.UploadFile(path); err != nil {
+		fatalf(wt.t, "UploadFile(%q): %s", path, err)
+	}
+	return
+}
+
+func (wt *webDriverT) LogTypes() (types []string) {
+	var err error
+	if types, err = wt.d.LogTypes(); err != nil {
+		fatalf(wt.t, "LogTypes: %s", err)
+	}
+	return
+}
+
+func (wt *webDriverT) Log(logType string) (entries []LogEntry) {
+	var err error
+	if entries, err = wt.d.Log(logType); err != nil {
+		fatalf(wt.t, "Log(%q): %s", logType, err)
+	}
+	return
+}
+
 func (wt *webDriverT) DismissAlert() {
 	if err := wt.d.DismissAlert(); err != nil {
 		fatalf(wt.t, "DismissAlert: %s", err)
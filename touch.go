@@ -0,0 +1,248 @@
+package selenium
+
+import (
+	"errors"
+	"math"
+)
+
+// TouchScreen performs touch gestures on mobile browsers, e.g. through
+// Appium. JSON Wire sessions use the /touch endpoints; W3C sessions use
+// pointer actions of type "touch". Coordinates are relative to the
+// viewport.
+type TouchScreen interface {
+	/* Tap an element. */
+	Tap(elem WebElement) error
+	/* Tap at a position. */
+	TapAt(x, y int) error
+	/* Double tap an element. */
+	DoubleTap(elem WebElement) error
+	/* Long press an element. */
+	LongPress(elem WebElement) error
+	/* Put a finger down at a position. */
+	Down(x, y int) error
+	/* Move the finger that is down to a position. */
+	Move(x, y int) error
+	/* Lift the finger that is down at a position. */
+	Up(x, y int) error
+	/* Scroll the page by an offset, starting on an element, or at the
+	   current finger position if elem is nil. */
+	Scroll(elem WebElement, xOffset, yOffset int) error
+	/* Flick starting on an element, moving by an offset at speed pixels per
+	   second. */
+	Flick(elem WebElement, xOffset, yOffset, speed int) error
+	/* Pinch on an element with two fingers, zooming out if scale < 1 and in
+	   if scale > 1. Always performed with W3C actions. */
+	Pinch(elem WebElement, scale float64) error
+}
+
+// longPressMs is how long LongPress holds the finger down on W3C sessions.
+const longPressMs = 1000
+
+// pinchDistance is the distance in pixels of each finger from the center of
+// the element at the start of a pinch.
+const pinchDistance = 50
+
+type touchScreen struct {
+	wd *remoteWebDriver
+}
+
+func (wd *remoteWebDriver) TouchScreen() TouchScreen {
+	return &touchScreen{wd}
+}
+
+// finger builds the W3C action sequence of a touch pointer.
+type finger struct {
+	id      string
+	actions []map[string]interface{}
+}
+
+func (f *finger) moveTo(origin interface{}, x, y, durationMs int) *finger {
+	f.actions = append(f.actions, map[string]interface{}{
+		"type": "pointerMove", "origin": origin, "x": x, "y": y, "duration": durationMs,
+	})
+	return f
+}
+
+func (f *finger) down() *finger {
+	f.actions = append(f.actions, map[string]interface{}{"type": "pointerDown", "button": 0})
+	return f
+}
+
+func (f *finger) up() *finger {
+	f.actions = append(f.actions, map[string]interface{}{"type": "pointerUp", "button": 0})
+	return f
+}
+
+func (f *finger) pause(durationMs int) *finger {
+	f.actions = append(f.actions, map[string]interface{}{"type": "pause", "duration": durationMs})
+	return f
+}
+
+func (f *finger) tap() *finger {
+	return f.down().up()
+}
+
+func (f *finger) source() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "pointer",
+		"id":         f.id,
+		"parameters": map[string]string{"pointerType": "touch"},
+		"actions":    f.actions,
+	}
+}
+
+// perform sends the fingers' action sequences in a single W3C actions
+// command.
+func (ts *touchScreen) perform(fingers ...*finger) error {
+	sources := make([]map[string]interface{}, len(fingers))
+	for i, f := range fingers {
+		sources[i] = f.source()
+	}
+	return ts.wd.voidCommand("/session/%s/actions", map[string]interface{}{"actions": sources})
+}
+
+// origin returns the W3C pointer origin of an element's center.
+func (ts *touchScreen) origin(elem WebElement) (interface{}, error) {
+	id, err := elementID(elem)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{webElementKey: id}, nil
+}
+
+// elementCommand sends a JSON Wire touch command on an element.
+func (ts *touchScreen) elementCommand(urlTemplate string, elem WebElement, params map[string]interface{}) error {
+	id, err := elementID(elem)
+	if err != nil {
+		return err
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	params["element"] = id
+	return ts.wd.voidCommand(urlTemplate, params)
+}
+
+// elementGesture performs a gesture on an element: with the given W3C
+// actions after moving the finger to the element's center, or with the
+// JSON Wire command at urlTemplate.
+func (ts *touchScreen) elementGesture(elem WebElement, urlTemplate string, gesture func(*finger)) error {
+	if !ts.wd.w3c {
+		return ts.elementCommand(urlTemplate, elem, nil)
+	}
+	origin, err := ts.origin(elem)
+	if err != nil {
+		return err
+	}
+	f := (&finger{id: "finger"}).moveTo(origin, 0, 0, 0)
+	gesture(f)
+	return ts.perform(f)
+}
+
+func (ts *touchScreen) Tap(elem WebElement) error {
+	return ts.elementGesture(elem, "/session/%s/touch/click", func(f *finger) {
+		f.tap()
+	})
+}
+
+func (ts *touchScreen) TapAt(x, y int) error {
+	if !ts.wd.w3c {
+		if err := ts.Down(x, y); err != nil {
+			return err
+		}
+		return ts.Up(x, y)
+	}
+	return ts.perform((&finger{id: "finger"}).moveTo("viewport", x, y, 0).tap())
+}
+
+func (ts *touchScreen) DoubleTap(elem WebElement) error {
+	return ts.elementGesture(elem, "/session/%s/touch/doubleclick", func(f *finger) {
+		f.tap().pause(100).tap()
+	})
+}
+
+func (ts *touchScreen) LongPress(elem WebElement) error {
+	return ts.elementGesture(elem, "/session/%s/touch/longclick", func(f *finger) {
+		f.down().pause(longPressMs).up()
+	})
+}
+
+func (ts *touchScreen) Down(x, y int) error {
+	if !ts.wd.w3c {
+		return ts.wd.voidCommand("/session/%s/touch/down", map[string]int{"x": x, "y": y})
+	}
+	return ts.perform((&finger{id: "finger"}).moveTo("viewport", x, y, 0).down())
+}
+
+func (ts *touchScreen) Move(x, y int) error {
+	if !ts.wd.w3c {
+		return ts.wd.voidCommand("/session/%s/touch/move", map[string]int{"x": x, "y": y})
+	}
+	return ts.perform((&finger{id: "finger"}).moveTo("viewport", x, y, 0))
+}
+
+func (ts *touchScreen) Up(x, y int) error {
+	if !ts.wd.w3c {
+		return ts.wd.voidCommand("/session/%s/touch/up", map[string]int{"x": x, "y": y})
+	}
+	return ts.perform((&finger{id: "finger"}).moveTo("viewport", x, y, 0).up())
+}
+
+func (ts *touchScreen) Scroll(elem WebElement, xOffset, yOffset int) error {
+	if !ts.wd.w3c {
+		params := map[string]interface{}{"xoffset": xOffset, "yoffset": yOffset}
+		if elem == nil {
+			return ts.wd.voidCommand("/session/%s/touch/scroll", params)
+		}
+		return ts.elementCommand("/session/%s/touch/scroll", elem, params)
+	}
+	var origin interface{} = "pointer"
+	if elem != nil {
+		var err error
+		if origin, err = ts.origin(elem); err != nil {
+			return err
+		}
+	}
+	// Scrolling the page by an offset drags the finger the opposite way.
+	f := (&finger{id: "finger"}).moveTo(origin, 0, 0, 0).down()
+	f.moveTo("pointer", -xOffset, -yOffset, 500).up()
+	return ts.perform(f)
+}
+
+func (ts *touchScreen) Flick(elem WebElement, xOffset, yOffset, speed int) error {
+	if elem == nil {
+		return errors.New("Flick: elem must not be nil")
+	}
+	if !ts.wd.w3c {
+		params := map[string]interface{}{"xoffset": xOffset, "yoffset": yOffset, "speed": speed}
+		return ts.elementCommand("/session/%s/touch/flick", elem, params)
+	}
+	origin, err := ts.origin(elem)
+	if err != nil {
+		return err
+	}
+	durationMs := 100
+	if speed > 0 {
+		distance := math.Hypot(float64(xOffset), float64(yOffset))
+		durationMs = int(distance / float64(speed) * 1000)
+	}
+	f := (&finger{id: "finger"}).moveTo(origin, 0, 0, 0).down()
+	f.moveTo("pointer", xOffset, yOffset, durationMs).up()
+	return ts.perform(f)
+}
+
+func (ts *touchScreen) Pinch(elem WebElement, scale float64) error {
+	if scale <= 0 {
+		return errors.New("Pinch: scale must be positive")
+	}
+	origin, err := ts.origin(elem)
+	if err != nil {
+		return err
+	}
+	end := int(pinchDistance * scale)
+	left := (&finger{id: "finger1"}).moveTo(origin, -pinchDistance, 0, 0).down()
+	left.moveTo(origin, -end, 0, 500).up()
+	right := (&finger{id: "finger2"}).moveTo(origin, pinchDistance, 0, 0).down()
+	right.moveTo(origin, end, 0, 500).up()
+	return ts.perform(left, right)
+}
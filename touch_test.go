@@ -0,0 +1,92 @@
+package selenium
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestTouchScreen_Tap(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/touch/click", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		if v["element"] != "e1" {
+			t.Errorf("element = %q, want e1", v["element"])
+		}
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	elem := &remoteWE{parent: client.(*remoteWebDriver), id: "e1"}
+	if err := client.TouchScreen().Tap(elem); err != nil {
+		t.Fatalf("Tap returned error: %v", err)
+	}
+}
+
+func TestTouchScreen_Tap_W3C(t *testing.T) {
+	setup()
+	defer teardown()
+	client.(*remoteWebDriver).w3c = true
+
+	var got interface{}
+	mux.HandleFunc("/session/123/actions", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"value": null}`)
+	})
+
+	elem := &remoteWE{parent: client.(*remoteWebDriver), id: "e1"}
+	if err := client.TouchScreen().Tap(elem); err != nil {
+		t.Fatalf("Tap returned error: %v", err)
+	}
+
+	var want interface{}
+	json.Unmarshal([]byte(`{"actions": [{
+		"type": "pointer",
+		"id": "finger",
+		"parameters": {"pointerType": "touch"},
+		"actions": [
+			{"type": "pointerMove", "origin": {"element-6066-11e4-a52e-4f735466cecf": "e1"}, "x": 0, "y": 0, "duration": 0},
+			{"type": "pointerDown", "button": 0},
+			{"type": "pointerUp", "button": 0}
+		]
+	}]}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("actions = %+v, want %+v", got, want)
+	}
+}
+
+func TestTouchScreen_Pinch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var got struct {
+		Actions []struct {
+			ID      string
+			Actions []map[string]interface{}
+		}
+	}
+	mux.HandleFunc("/session/123/actions", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	elem := &remoteWE{parent: client.(*remoteWebDriver), id: "e1"}
+	if err := client.TouchScreen().Pinch(elem, 0.5); err != nil {
+		t.Fatalf("Pinch returned error: %v", err)
+	}
+	if len(got.Actions) != 2 {
+		t.Fatalf("Pinch used %d fingers, want 2", len(got.Actions))
+	}
+	for i, wantX := range []float64{-25, 25} {
+		end := got.Actions[i].Actions[2]
+		if end["x"] != wantX {
+			t.Errorf("finger %d ends at x=%v, want %v", i, end["x"], wantX)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package selenium
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// A Tracer records the time taken by the commands of a WebDriver, and by
+// the helpers that send several of them, as spans. It can back metrics
+// such as counters and histograms as well as distributed tracing.
+type Tracer interface {
+	// Start starts a span, nested in parent unless it is nil. Commands are
+	// tagged with "session.id", "command.method" and "command.name", see
+	// Command.
+	Start(parent Span, name string, tags map[string]string) Span
+}
+
+// A Span is an operation timed by a Tracer.
+type Span interface {
+	// End ends the span, with the error the operation failed with, if any.
+	End(err error)
+}
+
+// NoopTracer is a Tracer that records nothing, like a WebDriver created
+// without WithTracer.
+type NoopTracer struct{}
+
+func (NoopTracer) Start(parent Span, name string, tags map[string]string) Span {
+	return noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(err error) {}
+
+// WithTracer records every command sent by the WebDriver with t, in a span
+// nested in the span of TraceSpan running it, if any.
+func WithTracer(t Tracer) RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.tracer = t
+		wd.middleware = append(wd.middleware, func(next Handler) Handler {
+			return func(cmd *Command) ([]byte, error) {
+				span := t.Start(wd.currentSpan(), cmd.Method+" "+cmd.Name, map[string]string{
+					"session.id":     cmd.SessionID,
+					"command.method": cmd.Method,
+					"command.name":   cmd.Name,
+				})
+				reply, err := next(cmd)
+				span.End(err)
+				return reply, err
+			}
+		})
+	}
+}
+
+func (wd *remoteWebDriver) currentSpan() Span {
+	wd.spanMu.Lock()
+	defer wd.spanMu.Unlock()
+	if n := len(wd.spans); n > 0 {
+		return wd.spans[n-1]
+	}
+	return nil
+}
+
+// TraceSpan runs fn in a span named name, in which the commands fn sends
+// through wd are nested. Use it for helpers that send many commands, such
+// as waits. It just runs fn if wd has no Tracer, see WithTracer.
+func TraceSpan(wd WebDriver, name string, fn func() error) error {
+	for {
+		w, ok := wd.(WrapsDriver)
+		if !ok {
+			break
+		}
+		wd = w.WrappedDriver()
+	}
+	rwd, ok := wd.(*remoteWebDriver)
+	if !ok || rwd.tracer == nil {
+		return fn()
+	}
+
+	span := rwd.tracer.Start(rwd.currentSpan(), name, map[string]string{"session.id": rwd.id})
+	rwd.spanMu.Lock()
+	rwd.spans = append(rwd.spans, span)
+	rwd.spanMu.Unlock()
+	defer func() {
+		rwd.spanMu.Lock()
+		rwd.spans = rwd.spans[:len(rwd.spans)-1]
+		rwd.spanMu.Unlock()
+	}()
+	err := fn()
+	span.End(err)
+	return err
+}
+
+// A Collector is a Tracer that keeps the spans in memory, e.g. to report
+// the slowest commands at the end of a test suite.
+type Collector struct {
+	mu    sync.Mutex
+	spans []*CollectedSpan
+}
+
+// A CollectedSpan is a span ended in a Collector.
+type CollectedSpan struct {
+	Name string
+	Tags map[string]string
+	// Parent is the span this one is nested in, or nil.
+	Parent   *CollectedSpan
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+
+	c *Collector
+}
+
+func (s *CollectedSpan) End(err error) {
+	s.Duration = time.Since(s.Start)
+	s.Err = err
+	s.c.mu.Lock()
+	s.c.spans = append(s.c.spans, s)
+	s.c.mu.Unlock()
+}
+
+func (c *Collector) Start(parent Span, name string, tags map[string]string) Span {
+	p, _ := parent.(*CollectedSpan)
+	return &CollectedSpan{Name: name, Tags: tags, Parent: p, Start: time.Now(), c: c}
+}
+
+// Spans returns the spans ended so far, in the order they ended.
+func (c *Collector) Spans() []*CollectedSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*CollectedSpan(nil), c.spans...)
+}
+
+// Slowest returns the n slowest commands, slowest first.
+func (c *Collector) Slowest(n int) []*CollectedSpan {
+	var commands []*CollectedSpan
+	for _, s := range c.Spans() {
+		if _, ok := s.Tags["command.name"]; ok {
+			commands = append(commands, s)
+		}
+	}
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].Duration > commands[j].Duration
+	})
+	if len(commands) > n {
+		commands = commands[:n]
+	}
+	return commands
+}
+
+// Report writes the n slowest commands to w, then the number, total and
+// maximum duration of the commands of each kind.
+func (c *Collector) Report(w io.Writer, n int) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "SLOWEST COMMANDS\tDURATION\tSESSION\tIN\n")
+	for _, s := range c.Slowest(n) {
+		in := ""
+		if s.Parent != nil {
+			in = s.Parent.Name
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Duration, s.Tags["session.id"], in)
+	}
+
+	type stats struct {
+		name       string
+		count      int
+		total, max time.Duration
+	}
+	byName := map[string]*stats{}
+	var all []*stats
+	for _, s := range c.Spans() {
+		st := byName[s.Name]
+		if st == nil {
+			st = &stats{name: s.Name}
+			byName[s.Name] = st
+			all = append(all, st)
+		}
+		st.count++
+		st.total += s.Duration
+		if s.Duration > st.max {
+			st.max = s.Duration
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].total > all[j].total })
+	fmt.Fprintf(tw, "\nSPAN\tCOUNT\tTOTAL\tMAX\n")
+	for _, st := range all {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", st.name, st.count, st.total, st.max)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,148 @@
+package selenium
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithTracer(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/title", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprint(w, `{"status": 0, "value": "t"}`)
+	})
+	mux.HandleFunc("/session/123/url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": "u"}`)
+	})
+
+	c := &Collector{}
+	wd, err := NewRemote(caps, server.URL, WithTracer(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errWait := errors.New("not yet")
+	err = TraceSpan(NewEventFiringWebDriver(wd, NoopListener{}), "wait", func() error {
+		wd.Title()
+		wd.CurrentURL()
+		return errWait
+	})
+	if err != errWait {
+		t.Errorf("TraceSpan returned %v, want the error of fn", err)
+	}
+	wd.CurrentURL()
+
+	spans := c.Spans()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	want := []string{
+		"POST /session",
+		"GET /session/:sessionId/title",
+		"GET /session/:sessionId/url",
+		"wait",
+		"GET /session/:sessionId/url",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("got spans %q, want %q", names, want)
+	}
+	if p := spans[1].Parent; p != spans[3] {
+		t.Errorf("command in TraceSpan has parent %v, want the wait span", p)
+	}
+	if spans[3].Err != errWait || spans[3].Duration < 10*time.Millisecond {
+		t.Errorf("wait span has error %v and duration %s", spans[3].Err, spans[3].Duration)
+	}
+	if p := spans[4].Parent; p != nil {
+		t.Errorf("command after TraceSpan has parent %v, want none", p)
+	}
+	if tags := spans[1].Tags; tags["session.id"] != "123" || tags["command.method"] != "GET" || tags["command.name"] != "/session/:sessionId/title" {
+		t.Errorf("got command tags %v", tags)
+	}
+
+	slowest := c.Slowest(1)
+	if len(slowest) != 1 || slowest[0] != spans[1] {
+		t.Errorf("Slowest(1) = %v, want the title command", slowest)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Report(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	report := buf.String()
+	for _, re := range []string{`SLOWEST COMMANDS`, `title`, `\nwait +1 `, `url +2 `} {
+		if !regexp.MustCompile(re).MatchString(report) {
+			t.Errorf("report does not match %q:\n%s", re, report)
+		}
+	}
+}
+
+func TestTraceSpan_NoTracer(t *testing.T) {
+	setup()
+	defer teardown()
+
+	ran := false
+	if err := TraceSpan(client, "wait", func() error { ran = true; return nil }); err != nil || !ran {
+		t.Errorf("TraceSpan without a tracer returned %v, ran fn: %v", err, ran)
+	}
+}
+
+func TestTraceSpan_Helpers(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/session/123/elements", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "ul1"}]}`)
+	})
+	mux.HandleFunc("/session/123/element/ul1/elements", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": [{"ELEMENT": "li1"}]}`)
+	})
+	mux.HandleFunc("/session/123/execute", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": 0, "value": {"headers": ["a"], "rows": [["1"]]}}`)
+	})
+
+	c := &Collector{}
+	wd, err := NewRemote(caps, server.URL, WithTracer(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elems, err := ByCSS("ul").Then(ByXPath("li")).FindElements(wd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadTable(wd, elems[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := c.Spans()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	want := []string{
+		"POST /session",
+		"POST /session/:sessionId/elements",
+		"POST /session/:sessionId/element/:id/elements",
+		`FindElements css selector "ul" > xpath "li"`,
+		"POST /session/:sessionId/execute",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("got spans %q, want %q", names, want)
+	}
+	for i, parent := range []int{-1, 3, 3, -1, -1} {
+		var want *CollectedSpan
+		if parent >= 0 {
+			want = spans[parent]
+		}
+		if spans[i].Parent != want {
+			t.Errorf("span %q has parent %v, want %v", spans[i].Name, spans[i].Parent, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package selenium
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// A FileDetector decides whether keys sent to an element name a local file.
+// It returns the local path of the file, or "" if the keys should be sent
+// unchanged.
+type FileDetector func(keys string) string
+
+// LocalFileDetector is a FileDetector that treats keys naming an existing
+// regular file on the local machine as a file to upload.
+func LocalFileDetector(keys string) string {
+	if fi, err := os.Stat(keys); err == nil && fi.Mode().IsRegular() {
+		return keys
+	}
+	return ""
+}
+
+// WithFileDetector makes SendKeys upload the files detected by fd to the
+// remote end and send their remote path instead, so that file inputs work
+// on a remote machine or Selenium Grid node.
+func WithFileDetector(fd FileDetector) RemoteOption {
+	return func(wd *remoteWebDriver) {
+		wd.fileDetector = fd
+	}
+}
+
+func (wd *remoteWebDriver) UploadFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// The remote end expects a base64 encoded zip archive holding the file.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create(filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	params := map[string]string{"file": base64.StdEncoding.EncodeToString(buf.Bytes())}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	// Selenium 4 moved the endpoint under its vendor prefix.
+	r, err := wd.send("POST", wd.url("/session/%s/se/file", wd.id), data)
+	if isUnknownCommand(err) {
+		r, err = wd.send("POST", wd.url("/session/%s/file", wd.id), data)
+	}
+	if err != nil {
+		return "", err
+	}
+	var remotePath string
+	err = r.readValue(&remotePath)
+	return remotePath, err
+}
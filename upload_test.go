@@ -0,0 +1,120 @@
+package selenium
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// handleUpload registers a legacy /file endpoint on mux that checks the
+// uploaded archive holds a single file with the given name and contents.
+func handleUpload(t *testing.T, name, contents string) {
+	mux.HandleFunc("/session/123/file", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		fail := func(format string, args ...interface{}) {
+			t.Errorf(format, args...)
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"status": 13, "value": {"message": "bad upload"}}`)
+		}
+
+		var v map[string]string
+		json.NewDecoder(r.Body).Decode(&v)
+		data, err := base64.StdEncoding.DecodeString(v["file"])
+		if err != nil {
+			fail("decoding upload: %s", err)
+			return
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			fail("reading upload archive: %s", err)
+			return
+		}
+		if len(zr.File) != 1 || zr.File[0].Name != name {
+			fail("upload archive holds %+v, want only %q", zr.File, name)
+			return
+		}
+		rc, _ := zr.File[0].Open()
+		got, _ := ioutil.ReadAll(rc)
+		if string(got) != contents {
+			t.Errorf("uploaded contents = %q, want %q", got, contents)
+		}
+
+		fmt.Fprint(w, `{"status": 0, "value": "/remote/`+name+`"}`)
+	})
+}
+
+func writeTempFile(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "selenium")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadFile(t *testing.T) {
+	setup()
+	defer teardown()
+
+	path := writeTempFile(t, "report.csv", "a,b\n1,2\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	handleUpload(t, "report.csv", "a,b\n1,2\n")
+
+	remotePath, err := client.UploadFile(path)
+	if err != nil {
+		t.Fatalf("UploadFile returned error: %v", err)
+	}
+	if want := "/remote/report.csv"; remotePath != want {
+		t.Errorf("UploadFile returned %q, want %q", remotePath, want)
+	}
+}
+
+func TestSendKeys_FileDetector(t *testing.T) {
+	setup()
+	defer teardown()
+
+	path := writeTempFile(t, "photo.png", "png")
+	defer os.RemoveAll(filepath.Dir(path))
+	handleUpload(t, "photo.png", "png")
+
+	var sent []string
+	mux.HandleFunc("/session/123/element/e1/value", func(w http.ResponseWriter, r *http.Request) {
+		var v map[string][]string
+		json.NewDecoder(r.Body).Decode(&v)
+		sent = v["value"]
+		fmt.Fprint(w, `{"status": 0}`)
+	})
+
+	wd, err := NewRemote(caps, server.URL, WithFileDetector(LocalFileDetector))
+	if err != nil {
+		t.Fatal(err)
+	}
+	elem := &remoteWE{parent: wd.(*remoteWebDriver), id: "e1"}
+
+	if err := elem.SendKeys(path); err != nil {
+		t.Fatalf("SendKeys returned error: %v", err)
+	}
+	if got, want := strings.Join(sent, ""), "/remote/photo.png"; got != want {
+		t.Errorf("SendKeys sent %q, want %q", got, want)
+	}
+
+	// Keys that don't name a file are sent unchanged.
+	if err := elem.SendKeys("hello"); err != nil {
+		t.Fatalf("SendKeys returned error: %v", err)
+	}
+	if want := []string{"h", "e", "l", "l", "o"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("SendKeys sent %q, want %q", sent, want)
+	}
+}
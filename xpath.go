@@ -0,0 +1,428 @@
+package selenium
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateXPath checks the syntax of an XPath 1.0 expression, returning a
+// *SelectorError if it is invalid.
+func ValidateXPath(expr string) error {
+	if strings.TrimSpace(expr) == "" {
+		return &SelectorError{By: ByXPATH, Selector: expr, Msg: "empty expression"}
+	}
+	toks, err := lexXPath(expr)
+	if err != nil {
+		return err
+	}
+	p := &xpathParser{expr: expr, toks: toks}
+	if err := p.binary(0); err != nil {
+		return err
+	}
+	if t := p.peek(); t.kind != xEOF {
+		return p.errorf(t.pos, "unexpected %s", t)
+	}
+	return nil
+}
+
+type xpathKind int
+
+const (
+	xEOF xpathKind = iota
+	xPunct
+	xOperator
+	xName
+	xNodeType
+	xFunction
+	xAxis
+	xLiteral
+	xNumber
+	xVariable
+)
+
+type xpathToken struct {
+	kind  xpathKind
+	value string
+	pos   int
+}
+
+func (t xpathToken) String() string {
+	if t.kind == xEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+var xpathAxes = map[string]bool{
+	"ancestor":           true,
+	"ancestor-or-self":   true,
+	"attribute":          true,
+	"child":              true,
+	"descendant":         true,
+	"descendant-or-self": true,
+	"following":          true,
+	"following-sibling":  true,
+	"namespace":          true,
+	"parent":             true,
+	"preceding":          true,
+	"preceding-sibling":  true,
+	"self":               true,
+}
+
+var xpathNodeTypes = map[string]bool{
+	"comment":                true,
+	"node":                   true,
+	"processing-instruction": true,
+	"text":                   true,
+}
+
+func isXPathSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNCNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isNCNameChar(c byte) bool {
+	return isNCNameStart(c) || isDigit(c) || c == '-' || c == '.'
+}
+
+// lexXPath splits expr into tokens, telling operators from names and name
+// tests from function names and axes by their context as the XPath 1.0
+// specification says.
+func lexXPath(expr string) ([]xpathToken, error) {
+	var toks []xpathToken
+	errorf := func(pos int, format string, args ...interface{}) error {
+		return &SelectorError{By: ByXPATH, Selector: expr, Offset: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+	// operand reports whether the last token ends an operand, so that the
+	// next * or name is an operator.
+	operand := func() bool {
+		if len(toks) == 0 {
+			return false
+		}
+		switch t := toks[len(toks)-1]; t.kind {
+		case xOperator:
+			return false
+		case xPunct:
+			return t.value == ")" || t.value == "]" || t.value == "." || t.value == ".."
+		}
+		return true
+	}
+	at := func(i int) byte {
+		if i < len(expr) {
+			return expr[i]
+		}
+		return 0
+	}
+	ncname := func(i int) int {
+		for i < len(expr) && isNCNameChar(expr[i]) {
+			i++
+		}
+		return i
+	}
+
+	for i := 0; ; {
+		for i < len(expr) && isXPathSpace(expr[i]) {
+			i++
+		}
+		if i == len(expr) {
+			return append(toks, xpathToken{xEOF, "", i}), nil
+		}
+		start := i
+		kind := xOperator
+		switch c := expr[i]; {
+		case strings.IndexByte("()[],@", c) >= 0:
+			kind = xPunct
+			i++
+		case c == '.' && isDigit(at(i+1)), isDigit(c):
+			kind = xNumber
+			for i < len(expr) && isDigit(expr[i]) {
+				i++
+			}
+			if at(i) == '.' {
+				for i++; i < len(expr) && isDigit(expr[i]); i++ {
+				}
+			}
+		case c == '.':
+			kind = xPunct
+			if i++; at(i) == '.' {
+				i++
+			}
+		case c == ':':
+			if at(i+1) != ':' {
+				return nil, errorf(i, "unexpected ':'")
+			}
+			kind = xPunct
+			i += 2
+		case c == '/':
+			if i++; at(i) == '/' {
+				i++
+			}
+		case c == '<' || c == '>':
+			if i++; at(i) == '=' {
+				i++
+			}
+		case c == '!':
+			if at(i+1) != '=' {
+				return nil, errorf(i, "expected '=' after '!'")
+			}
+			i += 2
+		case strings.IndexByte("|+-=", c) >= 0:
+			i++
+		case c == '*':
+			if !operand() {
+				kind = xName
+			}
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, errorf(i, "unterminated string literal")
+			}
+			kind = xLiteral
+			i += end + 2
+		case c == '$':
+			if !isNCNameStart(at(i + 1)) {
+				return nil, errorf(i+1, "expected variable name, found %s", found(expr, i+1, "end of expression"))
+			}
+			kind = xVariable
+			if i = ncname(i + 1); at(i) == ':' && isNCNameStart(at(i+1)) {
+				i = ncname(i + 1)
+			}
+		case isNCNameStart(c):
+			i = ncname(i)
+			if at(i) == ':' && at(i+1) != ':' {
+				switch {
+				case at(i+1) == '*':
+					i += 2
+				case isNCNameStart(at(i + 1)):
+					i = ncname(i + 1)
+				default:
+					return nil, errorf(i+1, "expected name or '*' after ':', found %s", found(expr, i+1, "end of expression"))
+				}
+			}
+			name := expr[start:i]
+			if operand() {
+				if name != "and" && name != "or" && name != "mod" && name != "div" {
+					return nil, errorf(start, "expected operator, found %q", name)
+				}
+				break
+			}
+			next := i
+			for next < len(expr) && isXPathSpace(expr[next]) {
+				next++
+			}
+			switch {
+			case at(next) == '(' && xpathNodeTypes[name]:
+				kind = xNodeType
+			case at(next) == '(':
+				kind = xFunction
+			case strings.HasPrefix(expr[next:], "::"):
+				if !xpathAxes[name] {
+					return nil, errorf(start, "unknown axis %q", name)
+				}
+				kind = xAxis
+			default:
+				kind = xName
+			}
+		default:
+			return nil, errorf(i, "unexpected %s", found(expr, i, ""))
+		}
+		toks = append(toks, xpathToken{kind, expr[start:i], start})
+	}
+}
+
+type xpathParser struct {
+	expr string
+	toks []xpathToken
+	i    int
+}
+
+func (p *xpathParser) errorf(pos int, format string, args ...interface{}) error {
+	return &SelectorError{By: ByXPATH, Selector: p.expr, Offset: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *xpathParser) expected(what string) error {
+	t := p.peek()
+	return p.errorf(t.pos, "expected %s, found %s", what, t)
+}
+
+func (p *xpathParser) peek() xpathToken {
+	return p.toks[p.i]
+}
+
+// accept consumes the next token if it is of kind and one of values.
+func (p *xpathParser) accept(kind xpathKind, values ...string) bool {
+	t := p.peek()
+	if t.kind != kind {
+		return false
+	}
+	for _, v := range values {
+		if t.value == v {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *xpathParser) expect(value string) error {
+	if !p.accept(xPunct, value) {
+		return p.expected(fmt.Sprintf("%q", value))
+	}
+	return nil
+}
+
+// xpathLevels are the binary operators by increasing precedence.
+var xpathLevels = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "div", "mod"},
+}
+
+// binary parses an expression of the operators of xpathLevels[level] and
+// higher.
+func (p *xpathParser) binary(level int) error {
+	if level == len(xpathLevels) {
+		for p.accept(xOperator, "-") {
+		}
+		return p.union()
+	}
+	for {
+		if err := p.binary(level + 1); err != nil {
+			return err
+		}
+		if !p.accept(xOperator, xpathLevels[level]...) {
+			return nil
+		}
+	}
+}
+
+func (p *xpathParser) union() error {
+	for {
+		if err := p.path(); err != nil {
+			return err
+		}
+		if !p.accept(xOperator, "|") {
+			return nil
+		}
+	}
+}
+
+func (p *xpathParser) path() error {
+	switch t := p.peek(); {
+	case t.kind == xVariable || t.kind == xLiteral || t.kind == xNumber || t.kind == xFunction || t.kind == xPunct && t.value == "(":
+		if err := p.primary(); err != nil {
+			return err
+		}
+		if err := p.predicates(); err != nil {
+			return err
+		}
+		if p.accept(xOperator, "/", "//") {
+			return p.relativePath()
+		}
+		return nil
+	case p.accept(xOperator, "/"):
+		if t := p.peek(); t.kind == xName || t.kind == xNodeType || t.kind == xAxis || t.kind == xPunct && (t.value == "@" || t.value == "." || t.value == "..") {
+			return p.relativePath()
+		}
+		return nil
+	case p.accept(xOperator, "//"):
+		return p.relativePath()
+	}
+	return p.relativePath()
+}
+
+func (p *xpathParser) relativePath() error {
+	for {
+		if err := p.step(); err != nil {
+			return err
+		}
+		if !p.accept(xOperator, "/", "//") {
+			return nil
+		}
+	}
+}
+
+func (p *xpathParser) step() error {
+	if p.accept(xPunct, ".", "..") {
+		return nil
+	}
+	if p.peek().kind == xAxis {
+		p.i++
+		if err := p.expect("::"); err != nil {
+			return err
+		}
+	} else {
+		p.accept(xPunct, "@")
+	}
+	switch t := p.peek(); t.kind {
+	case xName:
+		p.i++
+	case xNodeType:
+		p.i++
+		if err := p.expect("("); err != nil {
+			return err
+		}
+		if t.value == "processing-instruction" && p.peek().kind == xLiteral {
+			p.i++
+		}
+		if err := p.expect(")"); err != nil {
+			return err
+		}
+	default:
+		return p.expected("location step")
+	}
+	return p.predicates()
+}
+
+func (p *xpathParser) predicates() error {
+	for p.accept(xPunct, "[") {
+		if err := p.binary(0); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *xpathParser) primary() error {
+	switch t := p.peek(); t.kind {
+	case xVariable, xLiteral, xNumber:
+		p.i++
+	case xFunction:
+		p.i++
+		if err := p.expect("("); err != nil {
+			return err
+		}
+		if p.accept(xPunct, ")") {
+			return nil
+		}
+		for {
+			if err := p.binary(0); err != nil {
+				return err
+			}
+			if !p.accept(xPunct, ",") {
+				return p.expect(")")
+			}
+		}
+	default:
+		p.i++
+		if err := p.binary(0); err != nil {
+			return err
+		}
+		return p.expect(")")
+	}
+	return nil
+}